@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/impl"
+)
+
+// Constructs the agent for a provider, using the given model or the provider's default if empty.
+var agentFactories = map[string]func(ctx context.Context, model string) (agent.Agent, error){
+	"gemini": func(ctx context.Context, model string) (agent.Agent, error) {
+		return impl.NewGeminiAgent(ctx, &impl.GeminiOpts{Model: model})
+	},
+	"openai": func(ctx context.Context, model string) (agent.Agent, error) {
+		return impl.NewOpenAIAgent(&impl.OpenAIOpts{Model: model})
+	},
+	"anthropic": func(ctx context.Context, model string) (agent.Agent, error) {
+		return impl.NewAnthropicAgent(&impl.AnthropicOpts{Model: model})
+	},
+	"ollama": func(ctx context.Context, model string) (agent.Agent, error) {
+		var keepAlive time.Duration
+		if value := os.Getenv("RMCP_OLLAMA_KEEP_ALIVE"); value != "" {
+			var err error
+			if keepAlive, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid RMCP_OLLAMA_KEEP_ALIVE '%s': %s", value, err)
+			}
+		}
+		return impl.NewOllamaAgent(&impl.OllamaOpts{Model: model, KeepAlive: keepAlive})
+	},
+}
+
+func agentProviders() []string {
+	var providers []string
+	for provider := range agentFactories {
+		providers = append(providers, provider)
+	}
+	slices.Sort(providers)
+	return providers
+}
+
+func newAgent(ctx context.Context, provider string, model string) (agent.Agent, error) {
+	factory, ok := agentFactories[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown agent provider '%s'", provider)
+	}
+	return factory(ctx, model)
+}
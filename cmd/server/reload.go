@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+)
+
+// Reloads the host configuration stored at path whenever the file's content changes
+// or the process receives SIGHUP, until ctx is done.
+// The file is only checked for changes if interval is positive.
+func watchConfig(ctx context.Context, mcpHost *host.McpHost, path string, interval time.Duration) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	lastHash, _ := hashFile(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			log.Printf("Received SIGHUP; reloading %s", path)
+		case <-ticks:
+			hash, err := hashFile(path)
+			if err != nil || bytes.Equal(hash, lastHash) {
+				continue
+			}
+			log.Printf("%s changed; reloading", path)
+		}
+
+		lastHash, _ = hashFile(path)
+		reloadConfig(ctx, mcpHost, path)
+	}
+}
+
+// Applies the host configuration stored at path, returning an error only if the file is invalid.
+// Servers that cannot be started are logged and retried by the next reload, so that one
+// unreachable server does not keep the others from being served.
+func reloadConfig(ctx context.Context, mcpHost *host.McpHost, path string) error {
+	cfg, err := host.LoadConfigFile(path)
+	if err != nil {
+		log.Printf("Keeping the current configuration: %s", err)
+		return err
+	}
+
+	summary, err := mcpHost.ApplyConfig(ctx, cfg, nil)
+	if err != nil && len(summary.Failed) == 0 {
+		log.Printf("Keeping the current configuration: %s", err)
+		return err
+	}
+	log.Printf("Applied %s: %s", path, summary)
+	return nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
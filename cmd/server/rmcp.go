@@ -2,40 +2,216 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
-	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/impl"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/server"
 )
 
+const usage = `Usage: rmcp [command] [flags]
+
+Commands:
+  serve            Serve the remote MCP host API (the default command)
+  validate-config  Check a configuration file and report every error found
+  list-tools       List the tools offered by the configured servers
+  call-tool        Call a tool on a configured server and print its result
+
+Run 'rmcp <command> -h' for the flags of a command.
+Every flag may also be set with the environment variable shown in its description.
+`
+
+type command struct {
+	run func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"serve":           {run: serve},
+	"validate-config": {run: validateConfig},
+	"list-tools":      {run: listTools},
+	"call-tool":       {run: callTool},
+}
+
 func main() {
+	args := os.Args[1:]
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	ctx := context.Background()
+	if name == "help" {
+		fmt.Fprint(os.Stdout, usage)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
 
-	McpHost, err := host.NewMcpHost(nil)
-	if err != nil {
-		panic(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, args); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "rmcp %s: %s\n", name, err)
+		}
+		stop()
+		os.Exit(1)
+	}
+}
+
+// Flags shared by every command.
+type commonFlags struct {
+	config   string
+	logLevel string
+}
+
+func newFlagSet(name string, common *commonFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&common.config, "config", envOr("RMCP_CONFIG", ""), "path to the host configuration file (RMCP_CONFIG)")
+	fs.StringVar(&common.logLevel, "log-level", envOr("RMCP_LOG_LEVEL", "info"), "one of debug, info, warn or error (RMCP_LOG_LEVEL)")
+	return fs
+}
+
+// Parses the arguments and applies the common flags.
+func parseFlags(fs *flag.FlagSet, common *commonFlags, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(common.logLevel)); err != nil {
+		return fmt.Errorf("invalid log level '%s'", common.logLevel)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	return nil
+}
+
+func serve(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("serve", &common)
+	listen := fs.String("listen", envOr("RMCP_LISTEN", ":8080"), "address to serve the API on (RMCP_LISTEN)")
+	agentProvider := fs.String("agent", envOr("RMCP_AGENT", "gemini"), "agent provider: "+strings.Join(agentProviders(), ", ")+" (RMCP_AGENT)")
+	model := fs.String("model", envOr("RMCP_MODEL", ""), "model used by the agent; the provider's default if empty (RMCP_MODEL)")
+	samplingProvider := fs.String("sampling-agent", envOr("RMCP_SAMPLING_AGENT", ""), "agent provider answering sampling requests from servers; the -agent if empty (RMCP_SAMPLING_AGENT)")
+	samplingModel := fs.String("sampling-model", envOr("RMCP_SAMPLING_MODEL", ""), "model used for sampling; the provider's default if empty (RMCP_SAMPLING_MODEL)")
+	tlsCert := fs.String("tls-cert", envOr("RMCP_TLS_CERT", ""), "certificate file for serving HTTPS (RMCP_TLS_CERT)")
+	tlsKey := fs.String("tls-key", envOr("RMCP_TLS_KEY", ""), "key file for serving HTTPS (RMCP_TLS_KEY)")
+	reloadInterval := fs.Duration("reload-interval", envDurationOr("RMCP_RELOAD_INTERVAL", 2*time.Second), "how often the configuration file is checked for changes; 0 disables polling (RMCP_RELOAD_INTERVAL)")
+	adminAddr := fs.String("admin-addr", envOr("RMCP_ADMIN_ADDR", ""), "address to serve the admin API on; disabled if empty (RMCP_ADMIN_ADDR)")
+	adminToken := fs.String("admin-token", envOr("RMCP_ADMIN_TOKEN", ""), "bearer token required by the admin API (RMCP_ADMIN_TOKEN)")
+	if err := parseFlags(fs, &common, args); err != nil {
+		return err
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return fmt.Errorf("-tls-cert and -tls-key must be set together")
+	}
+	if *adminAddr != "" && *adminToken == "" {
+		return fmt.Errorf("-admin-token is required when -admin-addr is set")
 	}
 
-	err = McpHost.AddSessionsFromConfig(ctx, strings.NewReader("![./test_servers/greetings][greetings] go run greetings.go\n>[math]http://127.0.0.1:8080"), nil)
+	agent, err := newAgent(ctx, *agentProvider, *model)
 	if err != nil {
-		panic(err)
+		return err
+	}
+	sampler := agent
+	if *samplingProvider != "" || *samplingModel != "" {
+		if *samplingProvider == "" {
+			*samplingProvider = *agentProvider
+		}
+		if sampler, err = newAgent(ctx, *samplingProvider, *samplingModel); err != nil {
+			return err
+		}
 	}
 
-	agent, err := impl.NewGeminiAgent(ctx, nil)
+	mcpHost, err := host.NewMcpHost(&host.McpHostOptions{Sampler: sampler})
 	if err != nil {
-		panic(err)
+		return err
+	}
+	defer mcpHost.Close()
+
+	if common.config != "" {
+		if err := reloadConfig(ctx, &mcpHost, common.config); err != nil {
+			return err
+		}
+		go watchConfig(ctx, &mcpHost, common.config, *reloadInterval)
+	} else {
+		log.Printf("No configuration file given; serving without MCP servers")
+	}
+
+	servers := []*http.Server{{
+		Addr:    *listen,
+		Handler: server.NewRemoteMcpMux(&mcpHost, agent),
+	}}
+	if *adminAddr != "" {
+		servers = append(servers, &http.Server{
+			Addr: *adminAddr,
+			Handler: server.NewAdminMux(&mcpHost, &server.AdminOptions{
+				Token: *adminToken,
+			}),
+		})
 	}
-	mux := server.NewRemoteMcpMux(&McpHost, agent)
 
-	server := http.Server{
-		Handler: mux,
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			log.Printf("Listening on %s", srv.Addr)
+			var err error
+			if *tlsCert != "" {
+				err = srv.ListenAndServeTLS(*tlsCert, *tlsKey)
+			} else {
+				err = srv.ListenAndServe()
+			}
+			errs <- err
+		}()
 	}
 
-	err = server.ListenAndServe()
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, srv := range servers {
+		srv.Shutdown(shutdownCtx)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func envOr(key string, fallback string) string {
+	if val, ok := os.LookupEnv(key); ok {
+		return val
+	}
+	return fallback
+}
+
+func envDurationOr(key string, fallback time.Duration) time.Duration {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(val)
 	if err != nil {
-		panic(err)
+		log.Printf("Ignoring invalid %s '%s': %s", key, val, err)
+		return fallback
 	}
+	return d
 }
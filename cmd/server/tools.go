@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func validateConfig(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("validate-config", &common)
+	if err := parseFlags(fs, &common, args); err != nil {
+		return err
+	}
+	if common.config == "" {
+		return fmt.Errorf("-config is required")
+	}
+
+	cfg, err := host.LoadConfigFile(common.config)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d server(s) configured\n", common.config, len(cfg.Servers))
+	return nil
+}
+
+func listTools(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("list-tools", &common)
+	serverName := fs.String("server", "", "only list the tools of this server")
+	asJson := fs.Bool("json", false, "print the tools as JSON")
+	if err := parseFlags(fs, &common, args); err != nil {
+		return err
+	}
+
+	mcpHost, err := openHost(ctx, &common, *serverName)
+	if err != nil {
+		return err
+	}
+	defer mcpHost.Close()
+
+	var tools []*agent.ServerTool
+	if *serverName != "" {
+		serverTools, err := mcpHost.ListToolsOnServer(ctx, *serverName)
+		if err != nil {
+			return err
+		}
+		for _, tool := range serverTools {
+			tools = append(tools, &agent.ServerTool{ServerName: *serverName, Tool: tool})
+		}
+	} else {
+		var listingErr *agent.ToolListingError
+		tools, err = mcpHost.ListAllTools(ctx)
+		if errors.As(err, &listingErr) {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", listingErr)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if *asJson {
+		return printJson(tools)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVER\tTOOL\tDESCRIPTION")
+	for _, tool := range tools {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tool.ServerName, tool.Name, tool.Description)
+	}
+	return w.Flush()
+}
+
+func callTool(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("call-tool", &common)
+	serverName := fs.String("server", "", "server offering the tool")
+	toolName := fs.String("tool", "", "name of the tool to call")
+	arguments := fs.String("args", "{}", "arguments for the tool as a JSON object")
+	if err := parseFlags(fs, &common, args); err != nil {
+		return err
+	}
+	if *serverName == "" || *toolName == "" {
+		return fmt.Errorf("-server and -tool are required")
+	}
+
+	var toolArgs map[string]any
+	if err := json.Unmarshal([]byte(*arguments), &toolArgs); err != nil {
+		return fmt.Errorf("-args must be a JSON object: %s", err)
+	}
+
+	mcpHost, err := openHost(ctx, &common, *serverName)
+	if err != nil {
+		return err
+	}
+	defer mcpHost.Close()
+
+	client, err := mcpHost.GetClient(ctx, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.CallTool(ctx, &agent.ServerToolRequest{
+		ServerName: *serverName,
+		CallToolParams: mcp.CallToolParams{
+			Name:      *toolName,
+			Arguments: toolArgs,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := printJson(res); err != nil {
+		return err
+	}
+	// A call that timed out or was cancelled has no output of its own.
+	if res.Error != "" {
+		return fmt.Errorf("calling tool '%s': %s", *toolName, res.Error)
+	}
+	if res.Output.IsError {
+		return fmt.Errorf("tool '%s' reported an error", *toolName)
+	}
+	return nil
+}
+
+// Opens a host with the named server of the configuration file or, if the name is empty,
+// with every server of the file. Servers that cannot be started are then reported as warnings
+// and left out, so that they do not keep the others from being used.
+func openHost(ctx context.Context, common *commonFlags, serverName string) (*host.McpHost, error) {
+	if common.config == "" {
+		return nil, fmt.Errorf("-config is required")
+	}
+	cfg, err := host.LoadConfigFile(common.config)
+	if err != nil {
+		return nil, err
+	}
+
+	mcpHost, err := host.NewMcpHost(nil)
+	if err != nil {
+		return nil, err
+	}
+	if serverName != "" {
+		i := slices.IndexFunc(cfg.Servers, func(s host.ServerConfig) bool { return s.Name == serverName })
+		if i < 0 {
+			return nil, fmt.Errorf("server '%s' is not in %s", serverName, common.config)
+		}
+		if !cfg.Servers[i].IsEnabled() {
+			return nil, fmt.Errorf("server '%s' is disabled in %s", serverName, common.config)
+		}
+		if err := mcpHost.AddServers(ctx, &host.HostConfig{Servers: cfg.Servers[i : i+1]}, nil); err != nil {
+			mcpHost.Close()
+			return nil, err
+		}
+		return &mcpHost, nil
+	}
+
+	summary, err := mcpHost.ApplyConfig(ctx, cfg, nil)
+	if err != nil && len(summary.Failed) == 0 {
+		mcpHost.Close()
+		return nil, err
+	}
+	for _, name := range slices.Sorted(maps.Keys(summary.Failed)) {
+		fmt.Fprintf(os.Stderr, "warning: could not start server '%s': %s\n", name, summary.Failed[name])
+	}
+	return &mcpHost, nil
+}
+
+func printJson(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -2,7 +2,11 @@ module github.com/joshua-zingale/remote-mcp-host
 
 go 1.25.0
 
-require github.com/modelcontextprotocol/go-sdk v0.8.0
+require (
+	github.com/modelcontextprotocol/go-sdk v0.8.0
+	google.golang.org/genai v1.28.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	cloud.google.com/go v0.116.0 // indirect
@@ -20,7 +24,6 @@ require (
 	golang.org/x/net v0.29.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/text v0.18.0 // indirect
-	google.golang.org/genai v1.28.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
 	google.golang.org/grpc v1.66.2 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
@@ -10,14 +10,20 @@ import (
 type EchoAgent struct {
 }
 
-func (lm EchoAgent) Act(ctx context.Context, _ agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+func (lm EchoAgent) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+	return lm.Stream(ctx, client, messages, opts, nil)
+}
+
+func (lm EchoAgent) Stream(ctx context.Context, _ agent.McpClient, messages []api.Message, opts *agent.GenerateOptions, handler agent.EventHandler) (*agent.GenerateResult, error) {
 	text := "nothing to echo"
 	if len(messages) > 0 && len(messages[len(messages)-1].Parts) > 0 {
 		if tp, ok := messages[len(messages)-1].Parts[len(messages[len(messages)-1].Parts)-1].Part.(api.TextPart); ok {
 			text = tp.Text
 		}
 	}
+	handler.Emit(api.NewTextDeltaEvent(text))
 	return &agent.GenerateResult{
 		Message: api.NewModelMessage([]api.UnionPart{{Part: api.NewTextPart(text)}}),
+		Model:   "echo",
 	}, nil
 }
@@ -2,6 +2,10 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -12,21 +16,71 @@ type Agent interface {
 	// The messages should be ordered from oldest to newest.
 	// The options may be null, in which case default values should be used.
 	Act(context.Context, McpClient, []api.Message, *GenerateOptions) (*GenerateResult, error)
+	// Completes text like Act, reporting progress to the handler while generating.
+	// The handler receives text deltas and tool calls, but not the final message,
+	// which is returned as with Act.
+	// The handler may be null, in which case Stream behaves like Act.
+	Stream(context.Context, McpClient, []api.Message, *GenerateOptions, EventHandler) (*GenerateResult, error)
 }
 
+// EventHandler receives the events of a streamed generation.
+// It may be called concurrently, as tools may be called concurrently.
+type EventHandler func(api.GenerationEvent)
+
+// Passes the event to the handler, if there is one.
+func (h EventHandler) Emit(event api.GenerationEvent) {
+	if h != nil {
+		h(event)
+	}
+}
+
+// GenerateOptions steer the model. Agents leave out the options their model does not support.
 type GenerateOptions struct {
-	_ bool
+	// Instructions that steer the model, given in addition to the messages.
+	SystemPrompt string
+	// The maximum number of tokens to generate. Zero means the model's default.
+	MaxOutputTokens int
+	// The sampling temperature. Null means the model's default.
+	Temperature *float64
+	// The probability mass of the tokens sampled from. Null means the model's default.
+	TopP *float64
+	// Sequences at which the model stops generating.
+	StopSequences []string
+	// The most responses in which the model may call tools before it must answer.
+	// Zero means the agent's default.
+	MaxToolRounds int
+	// Makes sampling repeatable, as far as the model allows. Null means a random seed.
+	Seed *int
 }
 
 type GenerateResult struct {
 	Message *api.Message
+	// The name of the model that generated the message, if known.
+	Model string
 }
 
 type McpClient interface {
 	CallTool(context.Context, *ServerToolRequest) (*api.ToolUsePart, error)
+	// Lists the tools of every server. If only some servers could not be listed,
+	// the tools of the others are returned along with a *ToolListingError.
 	ListTools(context.Context) ([]*ServerTool, error)
 }
 
+// ToolListingError reports the servers whose tools could not be listed.
+type ToolListingError struct {
+	// The error of each server, by name.
+	Errors map[string]error
+}
+
+func (e *ToolListingError) Error() string {
+	names := slices.Sorted(maps.Keys(e.Errors))
+	var msgs []string
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("'%s': %s", name, e.Errors[name]))
+	}
+	return fmt.Sprintf("could not list the tools of %d server(s): %s", len(names), strings.Join(msgs, "; "))
+}
+
 type ServerTool struct {
 	ServerName string
 	mcp.Tool
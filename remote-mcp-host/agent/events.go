@@ -0,0 +1,34 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// Wraps the client so that every tool call is reported to the handler
+// with a tool-call-started event before it is made and a tool-call-finished event after.
+// The client is returned unchanged if the handler is null.
+func WithToolEvents(client McpClient, handler EventHandler) McpClient {
+	if handler == nil {
+		return client
+	}
+	return toolEventClient{McpClient: client, handler: handler}
+}
+
+type toolEventClient struct {
+	McpClient
+	handler EventHandler
+}
+
+func (c toolEventClient) CallTool(ctx context.Context, toolRequest *ServerToolRequest) (*api.ToolUsePart, error) {
+	c.handler.Emit(api.NewToolCallStartedEvent(toolRequest.Arguments, *toolRequest.ToolId()))
+
+	res, err := c.McpClient.CallTool(ctx, toolRequest)
+	if err != nil {
+		c.handler.Emit(api.NewToolCallFinishedEvent(api.NewToolUsePartError(toolRequest.Arguments, err.Error(), *toolRequest.ToolId())))
+		return nil, err
+	}
+	c.handler.Emit(api.NewToolCallFinishedEvent(*res))
+	return res, nil
+}
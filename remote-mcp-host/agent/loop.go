@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// The number of requests a Loop makes to its model per generation if it sets none.
+const DefaultMaxRequests = 3
+
+const (
+	continueInstruction = "The Responses from the tool calls are not visible to the user. Continue your response to the user based on the tool results in natural language. You may call additional tools, but only if necessary."
+	concludeInstruction = "The Responses from the tool calls are not visible to the user. Continue your response to the user based on the tool results in natural language. You must conclude your message to the user with this message."
+)
+
+// ModelAdapter connects a model provider to a Loop. It converts the requests of the loop
+// to those of the provider and parses the provider's responses, while the loop calls the tools.
+type ModelAdapter interface {
+	// Names a tool in the requests to the model. Different tools must be given different names.
+	// A tool ID without a server name is of a tool that the model called but that does not exist,
+	// and its name, which is the one the model called, must be kept as it is.
+	ToolName(toolId api.ToolId) string
+	// Makes one request to the model, passing the text deltas of its response to the handler.
+	Generate(ctx context.Context, request *ModelRequest, handler EventHandler) (*ModelResponse, error)
+}
+
+type ModelRequest struct {
+	// The messages, ending with a model message holding the parts generated so far, if there are any.
+	Messages []api.Message
+	// The instructions for the model: the system prompt of the options followed by those of the loop.
+	// May be empty.
+	SystemPrompt string
+	// The tools that the model may call. Empty on the final request, which must answer the user.
+	Tools []ModelTool
+	// Never null.
+	Options *GenerateOptions
+}
+
+// ModelTool is a tool under the name given to it by the adapter.
+type ModelTool struct {
+	Name string
+	*ServerTool
+}
+
+type ModelResponse struct {
+	Text      string
+	ToolCalls []ModelToolCall
+}
+
+type ModelToolCall struct {
+	// The name of the tool, as given by the adapter.
+	Name      string
+	Arguments map[string]any
+}
+
+// Loop is an Agent that generates by requesting its model until the model stops calling tools.
+// The tools called in one response are called concurrently. The final request gives the model
+// no tools, so that it concludes its answer.
+//
+// Tools that fail or are unknown are reported to the model as tool uses with an error,
+// whereas the generation fails if the model cannot be requested or the context is done.
+type Loop struct {
+	Model ModelAdapter
+	// The name of the model, reported with each result.
+	ModelName string
+	// The most requests made to the model per generation, unless the options set the most tool rounds.
+	// Defaults to DefaultMaxRequests. With one request, the model is given no tools.
+	MaxRequests int
+}
+
+func (l Loop) Act(ctx context.Context, client McpClient, messages []api.Message, opts *GenerateOptions) (*GenerateResult, error) {
+	return l.Stream(ctx, client, messages, opts, nil)
+}
+
+func (l Loop) Stream(ctx context.Context, client McpClient, messages []api.Message, opts *GenerateOptions, handler EventHandler) (*GenerateResult, error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	maxRequests := l.MaxRequests
+	if maxRequests <= 0 {
+		maxRequests = DefaultMaxRequests
+	}
+	if opts.MaxToolRounds > 0 {
+		// The request after the last round must answer without tools.
+		maxRequests = opts.MaxToolRounds + 1
+	}
+	client = WithToolEvents(client, handler)
+
+	var generatedParts []api.UnionPart
+	instruction := ""
+	for i := range maxRequests {
+		final := i == maxRequests-1
+		// The model is only made to conclude after calling tools.
+		if final && i > 0 {
+			instruction = concludeInstruction
+		}
+		parts, numToolsCalled, err := l.step(ctx, client, messages, generatedParts, opts, instruction, final, handler)
+		if err != nil {
+			return nil, err
+		}
+		generatedParts = append(generatedParts, parts...)
+		if numToolsCalled == 0 {
+			break
+		}
+		instruction = continueInstruction
+	}
+
+	return &GenerateResult{
+		Message: api.NewModelMessage(generatedParts),
+		Model:   l.ModelName,
+	}, nil
+}
+
+// Makes one request to the model and calls the tools it asks for.
+// Returns the generated parts and the number of tools called.
+func (l Loop) step(ctx context.Context, client McpClient, messages []api.Message, generatedParts []api.UnionPart, opts *GenerateOptions, instruction string, final bool, handler EventHandler) ([]api.UnionPart, int, error) {
+	request := &ModelRequest{
+		Messages:     messages,
+		SystemPrompt: joinNonEmpty("\n\n", opts.SystemPrompt, instruction),
+		Options:      opts,
+	}
+	if len(generatedParts) > 0 {
+		request.Messages = append(messages[:len(messages):len(messages)], *api.NewModelMessage(generatedParts))
+	}
+
+	toolIds := make(map[string]api.ToolId)
+	if !final {
+		serverTools, err := client.ListTools(ctx)
+		var listingErr *ToolListingError
+		if errors.As(err, &listingErr) {
+			// The model is given the tools of the servers that could be listed.
+			log.Printf("Generating without some tools: %s", listingErr)
+		} else if err != nil {
+			return nil, 0, err
+		}
+		for _, t := range serverTools {
+			name := l.Model.ToolName(*t.ToolId())
+			if other, ok := toolIds[name]; ok && other != *t.ToolId() {
+				return nil, 0, fmt.Errorf("tool '%s' of server '%s' and tool '%s' of server '%s' are both named '%s' for the model",
+					other.Name, other.ServerName, t.Name, t.ServerName, name)
+			}
+			toolIds[name] = *t.ToolId()
+			request.Tools = append(request.Tools, ModelTool{Name: name, ServerTool: t})
+		}
+	}
+
+	res, err := l.Model.Generate(ctx, request, handler)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var parts []api.UnionPart
+	if len(res.Text) > 0 {
+		parts = append(parts, api.ToUnion(api.NewTextPart(res.Text)))
+	}
+
+	uses := make([]api.ToolUsePart, len(res.ToolCalls))
+	var wg sync.WaitGroup
+	for i, call := range res.ToolCalls {
+		args := call.Arguments
+		if args == nil {
+			args = map[string]any{}
+		}
+		toolId, ok := toolIds[call.Name]
+		if !ok {
+			// Without a server, the tool keeps the name the model called it by.
+			uses[i] = api.NewToolUsePartError(args, unknownToolMessage(call.Name, final), api.ToolId{Name: call.Name})
+			continue
+		}
+		wg.Go(func() {
+			uses[i] = callTool(ctx, client, &ServerToolRequest{
+				ServerName:     toolId.ServerName,
+				CallToolParams: mcp.CallToolParams{Name: toolId.Name, Arguments: args},
+			})
+		})
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		// The caller went away, so the generation ends here.
+		return nil, 0, ctx.Err()
+	}
+
+	for _, use := range uses {
+		parts = append(parts, api.ToUnion(use))
+	}
+	return parts, len(uses), nil
+}
+
+// Calls the tool, reporting a failure to call it in the returned part so that the model may see it.
+func callTool(ctx context.Context, client McpClient, toolRequest *ServerToolRequest) api.ToolUsePart {
+	res, err := client.CallTool(ctx, toolRequest)
+	if err != nil {
+		return api.NewToolUsePartError(toolRequest.Arguments, err.Error(), *toolRequest.ToolId())
+	}
+	return api.NewToolUsePart(toolRequest.Arguments, res.Output, res.ToolId)
+}
+
+func unknownToolMessage(name string, final bool) string {
+	if final {
+		return "no tools are available"
+	}
+	return fmt.Sprintf("unknown tool '%s'", name)
+}
+
+func joinNonEmpty(sep string, elems ...string) string {
+	var joined string
+	for _, elem := range elems {
+		if elem == "" {
+			continue
+		}
+		if joined != "" {
+			joined += sep
+		}
+		joined += elem
+	}
+	return joined
+}
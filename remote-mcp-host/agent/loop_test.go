@@ -0,0 +1,180 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// scriptedModel answers each request with the next of its responses.
+type scriptedModel struct {
+	responses []*ModelResponse
+	requests  []*ModelRequest
+}
+
+func (m *scriptedModel) ToolName(toolId api.ToolId) string {
+	return toolId.ServerName + "/" + toolId.Name
+}
+
+func (m *scriptedModel) Generate(ctx context.Context, request *ModelRequest, handler EventHandler) (*ModelResponse, error) {
+	m.requests = append(m.requests, request)
+	if len(m.requests) > len(m.responses) {
+		return nil, fmt.Errorf("no response for request %d", len(m.requests))
+	}
+	res := m.responses[len(m.requests)-1]
+	handler.Emit(api.NewTextDeltaEvent(res.Text))
+	return res, nil
+}
+
+// waitingClient serves a tool that only answers once all expected calls are in flight,
+// so that the calls must be made concurrently.
+type waitingClient struct {
+	arrived sync.WaitGroup
+}
+
+func (c *waitingClient) ListTools(ctx context.Context) ([]*ServerTool, error) {
+	return []*ServerTool{{ServerName: "s", Tool: mcp.Tool{Name: "echo"}}}, nil
+}
+
+func (c *waitingClient) CallTool(ctx context.Context, toolRequest *ServerToolRequest) (*api.ToolUsePart, error) {
+	c.arrived.Done()
+	c.arrived.Wait()
+	args := toolRequest.Arguments.(map[string]any)
+	if args["fail"] == true {
+		return nil, fmt.Errorf("failed as asked")
+	}
+	part := api.NewToolUsePart(args, mcp.CallToolResult{StructuredContent: args}, *toolRequest.ToolId())
+	return &part, nil
+}
+
+func TestLoopCallsToolsConcurrently(t *testing.T) {
+	model := &scriptedModel{responses: []*ModelResponse{
+		{Text: "Calling.", ToolCalls: []ModelToolCall{
+			{Name: "s/echo", Arguments: map[string]any{"n": 1.0}},
+			{Name: "s/echo", Arguments: map[string]any{"fail": true}},
+			{Name: "s/missing"},
+			{Name: "s/echo", Arguments: map[string]any{"n": 3.0}},
+		}},
+		{Text: "Done."},
+	}}
+	client := &waitingClient{}
+	client.arrived.Add(3)
+
+	var mu sync.Mutex
+	var events []string
+	res, err := Loop{Model: model, ModelName: "scripted"}.Stream(context.Background(), client, nil, &GenerateOptions{SystemPrompt: "Be brief."}, func(event api.GenerationEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event.Type)
+	})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if res.Model != "scripted" || len(res.Message.Parts) != 6 {
+		t.Fatalf("expected the text, four tool uses and the answer but found %+v", res)
+	}
+	var uses []api.ToolUsePart
+	for _, part := range res.Message.Parts[1:5] {
+		uses = append(uses, part.Part.(api.ToolUsePart))
+	}
+	if uses[0].Output.StructuredContent.(map[string]any)["n"] != 1.0 || uses[3].Output.StructuredContent.(map[string]any)["n"] != 3.0 {
+		t.Errorf("expected the tool uses in the order of the calls but found %+v", uses)
+	}
+	if uses[1].Error != "failed as asked" || uses[2].Error != "unknown tool 's/missing'" {
+		t.Errorf("expected the failed and unknown tools to be reported to the model but found %+v", uses)
+	}
+	if strings.Count(strings.Join(events, " "), "tool-call-started") != 3 {
+		t.Errorf("expected an event for each tool called but found %v", events)
+	}
+
+	if len(model.requests) != 2 {
+		t.Fatalf("expected two requests but found %d", len(model.requests))
+	}
+	first, second := model.requests[0], model.requests[1]
+	if first.SystemPrompt != "Be brief." || len(first.Tools) != 1 || first.Tools[0].Name != "s/echo" {
+		t.Errorf("expected the first request to hold the system prompt and tools but found %+v", first)
+	}
+	if !strings.HasPrefix(second.SystemPrompt, "Be brief.\n\n") || len(second.Tools) != 1 {
+		t.Errorf("expected the second request to be told to continue but found %+v", second)
+	}
+	if len(second.Messages) != 1 || second.Messages[0].Role != "model" || len(second.Messages[0].Parts) != 5 {
+		t.Errorf("expected the second request to hold the parts generated so far but found %+v", second.Messages)
+	}
+}
+
+func TestLoopForcesFinalAnswer(t *testing.T) {
+	call := ModelToolCall{Name: "s/echo", Arguments: map[string]any{}}
+	model := &scriptedModel{responses: []*ModelResponse{
+		{ToolCalls: []ModelToolCall{call}},
+		{ToolCalls: []ModelToolCall{call}},
+	}}
+	client := &waitingClient{}
+	client.arrived.Add(1)
+
+	// The rounds of the options take precedence over the loop's requests.
+	res, err := Loop{Model: model, MaxRequests: 5}.Act(context.Background(), client, nil, &GenerateOptions{MaxToolRounds: 1})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(model.requests) != 2 || len(model.requests[1].Tools) != 0 || !strings.Contains(model.requests[1].SystemPrompt, "must conclude") {
+		t.Errorf("expected the final request to have no tools and to ask for a conclusion but found %+v", model.requests)
+	}
+	if last := res.Message.Parts[len(res.Message.Parts)-1].Part.(api.ToolUsePart); last.Error != "no tools are available" {
+		t.Errorf("expected a tool called in the final response not to be called but found %+v", last)
+	}
+}
+
+// listingClient offers tools that it cannot call.
+type listingClient struct {
+	tools []*ServerTool
+}
+
+func (c *listingClient) ListTools(ctx context.Context) ([]*ServerTool, error) {
+	return c.tools, nil
+}
+
+func (c *listingClient) CallTool(ctx context.Context, toolRequest *ServerToolRequest) (*api.ToolUsePart, error) {
+	return nil, fmt.Errorf("cannot call tools")
+}
+
+func TestLoopRejectsToolNameCollisions(t *testing.T) {
+	model := &scriptedModel{responses: []*ModelResponse{{Text: "Done."}}}
+	// The model names both tools "s/a/b".
+	client := &listingClient{tools: []*ServerTool{
+		{ServerName: "s", Tool: mcp.Tool{Name: "a/b"}},
+		{ServerName: "s/a", Tool: mcp.Tool{Name: "b"}},
+	}}
+
+	_, err := Loop{Model: model}.Act(context.Background(), client, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "both named 's/a/b'") {
+		t.Errorf("expected the tools' names to collide but found %v", err)
+	}
+	if len(model.requests) != 0 {
+		t.Errorf("expected the model not to be requested but found %d request(s)", len(model.requests))
+	}
+}
+
+func TestLoopWithOneRequestGivesNoTools(t *testing.T) {
+	model := &scriptedModel{responses: []*ModelResponse{
+		{Text: "Calling.", ToolCalls: []ModelToolCall{{Name: "s/echo"}}},
+	}}
+
+	res, err := Loop{Model: model, MaxRequests: 1}.Act(context.Background(), &waitingClient{}, nil, &GenerateOptions{SystemPrompt: "Be brief."})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(model.requests) != 1 || len(model.requests[0].Tools) != 0 || model.requests[0].SystemPrompt != "Be brief." {
+		t.Errorf("expected a single request without tools or the instruction to conclude but found %+v", model.requests)
+	}
+	if use := res.Message.Parts[1].Part.(api.ToolUsePart); use.Error != "no tools are available" || use.ToolId != (api.ToolId{Name: "s/echo"}) {
+		t.Errorf("expected the tool not to be called and to keep the model's name but found %+v", use)
+	}
+}
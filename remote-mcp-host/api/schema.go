@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -11,14 +12,58 @@ type McpServerList struct {
 	Servers []McpServerListing `json:"servers"`
 }
 
+// ServerLogList holds the most recent log entries of a server, from oldest to newest.
+type ServerLogList struct {
+	Logs []ServerLogEntry `json:"logs"`
+}
+
+type ServerLogEntry struct {
+	Time time.Time `json:"time"`
+	// "mcp" for log messages sent by the server, or "stderr" for lines that a stdio server wrote
+	// to its standard error.
+	Source string `json:"source"`
+	// An MCP logging level, such as "info" or "error".
+	Level   string `json:"level"`
+	Logger  string `json:"logger,omitempty"`
+	Message string `json:"message"`
+}
+
 type McpServerListing struct {
 	Name string `json:"name"`
+	// One of "connecting", "ready", "degraded", "failed" or "stopped".
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
 }
 
 type ToolList struct {
 	Tools []mcp.Tool `json:"tools"`
 }
 
+type ResourceList struct {
+	Resources []mcp.Resource `json:"resources"`
+}
+
+type ResourceTemplateList struct {
+	ResourceTemplates []mcp.ResourceTemplate `json:"resourceTemplates"`
+}
+
+type ResourceContentsList struct {
+	Contents []*mcp.ResourceContents `json:"contents"`
+}
+
+type PromptList struct {
+	Prompts []mcp.Prompt `json:"prompts"`
+}
+
+type PromptRequest struct {
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+type PromptResponse struct {
+	Description string    `json:"description,omitempty"`
+	Messages    []Message `json:"messages"`
+}
+
 type RoleType = string
 
 type TextPart struct {
@@ -35,6 +80,18 @@ type ToolUsePart struct {
 	Type   string             `json:"type"`
 }
 
+// ResourcePart holds the contents of a resource read from an MCP server.
+type ResourcePart struct {
+	Error      string `json:"error,omitempty"`
+	ServerName string `json:"serverName"`
+	URI        string `json:"uri"`
+	MimeType   string `json:"mimeType,omitempty"`
+	// Either Text or Blob holds the contents.
+	Text string `json:"text,omitempty"`
+	Blob []byte `json:"blob,omitempty"`
+	Type string `json:"type"`
+}
+
 type Message struct {
 	Parts []UnionPart `json:"parts"`
 	Role  RoleType    `json:"role"`
@@ -61,18 +118,108 @@ type ToolPatch struct {
 type ToolConfig struct {
 	ToolId    ToolId    `json:"toolId"`
 	ToolPatch ToolPatch `json:"toolPatch,omitempty"`
+	// How long a call to the tool may take, such as "30s",
+	// overriding the timeouts configured for its server.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ResourceRef names a resource offered by an MCP server.
+type ResourceRef struct {
+	ServerName string `json:"serverName"`
+	URI        string `json:"uri"`
+}
+
+// PromptRef names a prompt offered by an MCP server and the arguments to render it with.
+type PromptRef struct {
+	ServerName string            `json:"serverName"`
+	Name       string            `json:"name"`
+	Arguments  map[string]string `json:"arguments,omitempty"`
+}
+
+// RootRef is a directory that an MCP server may operate in, given as a file:// URI.
+type RootRef struct {
+	ServerName string `json:"serverName"`
+	URI        string `json:"uri"`
+	Name       string `json:"name,omitempty"`
 }
 
 type GenerationRequest struct {
+	// Identifies the generation while it runs, so that input requested during it can be answered.
+	// If empty, an identifier is chosen by the server.
+	GenerationId           string       `json:"generationId,omitempty"`
 	ToolConfigs            []ToolConfig `json:"toolConfigs,omitempty"`
 	Messages               []Message    `json:"messages"`
 	OnlyUseConfiguredTools bool         `json:"onlyIncludeConfiguredTools,omitempty"`
+	// Resources whose contents are added to the start of the last message before generating.
+	Resources []ResourceRef `json:"resources,omitempty"`
+	// A prompt whose rendered messages precede the Messages.
+	Prompt *PromptRef `json:"prompt,omitempty"`
+	// Roots announced to servers in addition to their configured ones while generating.
+	// As a server has one session, the roots are seen by every request to it meanwhile,
+	// including those of other generations.
+	Roots []RootRef `json:"roots,omitempty"`
+	GenerationOptions
+}
+
+// GenerationOptions steer the model. Every option is optional; models ignore those they do not support.
+type GenerationOptions struct {
+	// Instructions given to the model in addition to the messages.
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+	// Between 0 and 2.
+	Temperature *float64 `json:"temperature,omitempty"`
+	// Between 0 and 1.
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	// At most 16 non-empty sequences at which the model stops generating.
+	StopSequences []string `json:"stopSequences,omitempty"`
+	// The most responses in which the model may call tools before it must answer, at most 10.
+	MaxToolRounds int `json:"maxToolRounds,omitempty"`
+	// A 32-bit integer making sampling repeatable, as far as the model allows.
+	Seed *int `json:"seed,omitempty"`
 }
 
 type GenerationResponse struct {
 	Message Message `json:"message"`
 }
 
+// InputRequest is a request for input from the user made by an MCP server during a generation.
+type InputRequest struct {
+	Id         string `json:"id"`
+	ServerName string `json:"serverName"`
+	Message    string `json:"message"`
+	// A JSON schema of the flat object expected as the content of the answer.
+	RequestedSchema any `json:"requestedSchema,omitempty"`
+}
+
+// InputResponse answers an InputRequest.
+type InputResponse struct {
+	InputRequestId string `json:"inputRequestId"`
+	// One of "accept", "decline" or "cancel".
+	Action string `json:"action"`
+	// The user's input, matching the requested schema, if the action is "accept".
+	Content map[string]any `json:"content,omitempty"`
+}
+
+type GenerationStatus struct {
+	Id string `json:"id"`
+	// "running", or "input-required" while input requests are pending.
+	State         string         `json:"state"`
+	InputRequests []InputRequest `json:"inputRequests"`
+	// The latest progress reported for each of the generation's tool calls that reported any.
+	Progress []ToolProgress `json:"progress"`
+}
+
+// ToolProgress is the progress that a server reported for a tool call.
+type ToolProgress struct {
+	// Identifies the tool call, which may report progress several times.
+	CallId   string  `json:"callId"`
+	ToolId   ToolId  `json:"toolId"`
+	Progress float64 `json:"progress"`
+	// The progress at which the call is done, if known.
+	Total   float64 `json:"total,omitempty"`
+	Message string  `json:"message,omitempty"`
+}
+
 type Part interface {
 	PartType() string
 }
@@ -108,6 +255,12 @@ func (up *UnionPart) UnmarshalJSON(data []byte) error {
 			return err
 		}
 		up.Part = p
+	case "resource":
+		var p ResourcePart
+		if err := json.Unmarshal(data, &p); err != nil {
+			return err
+		}
+		up.Part = p
 	default:
 		return fmt.Errorf("unknown part type: %s", temp.Type)
 	}
@@ -163,6 +316,21 @@ func NewToolUsePartError(input any, errorText string, toolId ToolId) ToolUsePart
 	}
 }
 
+func NewResourcePart(serverName string, contents *mcp.ResourceContents) ResourcePart {
+	return ResourcePart{
+		ServerName: serverName,
+		URI:        contents.URI,
+		MimeType:   contents.MIMEType,
+		Text:       contents.Text,
+		Blob:       contents.Blob,
+		Type:       "resource",
+	}
+}
+
+func (r ResourcePart) PartType() string {
+	return "resource"
+}
+
 func NewModelMessage(parts []UnionPart) *Message {
 	return &Message{
 		Parts: parts,
@@ -173,3 +341,79 @@ func NewModelMessage(parts []UnionPart) *Message {
 func (t ToolUsePart) PartType() string {
 	return "tool-use"
 }
+
+// GenerationEvent is sent as a Server-Sent Event while a generation is streamed.
+// The fields that are set depend on the Type.
+type GenerationEvent struct {
+	// One of "text-delta", "tool-call-started", "tool-progress", "tool-call-finished",
+	// "input-required", "final-message" or "error".
+	Type string `json:"type"`
+	// The generation that an input-required event belongs to.
+	GenerationId string `json:"generationId,omitempty"`
+	// The input requested by an input-required event.
+	InputRequest *InputRequest `json:"inputRequest,omitempty"`
+	// The text generated since the previous text-delta.
+	Text string `json:"text,omitempty"`
+	// The tool being called by a tool-call-started event.
+	ToolId *ToolId `json:"toolId,omitempty"`
+	Input  any     `json:"input,omitempty"`
+	// The progress reported by a tool-progress event.
+	Progress *ToolProgress `json:"progress,omitempty"`
+	// The result of a tool-call-finished event.
+	ToolUse *ToolUsePart `json:"toolUse,omitempty"`
+	// The complete response of a final-message event.
+	Message *Message `json:"message,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+func NewTextDeltaEvent(text string) GenerationEvent {
+	return GenerationEvent{
+		Type: "text-delta",
+		Text: text,
+	}
+}
+
+func NewToolCallStartedEvent(input any, toolId ToolId) GenerationEvent {
+	return GenerationEvent{
+		Type:   "tool-call-started",
+		ToolId: &toolId,
+		Input:  input,
+	}
+}
+
+func NewToolCallFinishedEvent(toolUse ToolUsePart) GenerationEvent {
+	return GenerationEvent{
+		Type:    "tool-call-finished",
+		ToolId:  &toolUse.ToolId,
+		ToolUse: &toolUse,
+	}
+}
+
+func NewToolProgressEvent(progress ToolProgress) GenerationEvent {
+	return GenerationEvent{
+		Type:     "tool-progress",
+		Progress: &progress,
+	}
+}
+
+func NewInputRequiredEvent(generationId string, request InputRequest) GenerationEvent {
+	return GenerationEvent{
+		Type:         "input-required",
+		GenerationId: generationId,
+		InputRequest: &request,
+	}
+}
+
+func NewFinalMessageEvent(message Message) GenerationEvent {
+	return GenerationEvent{
+		Type:    "final-message",
+		Message: &message,
+	}
+}
+
+func NewErrorEvent(errorText string) GenerationEvent {
+	return GenerationEvent{
+		Type:  "error",
+		Error: errorText,
+	}
+}
@@ -0,0 +1,810 @@
+package host
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HostConfig is the declarative configuration of the MCP servers that a host connects to.
+// It may be written as JSON or YAML; see ParseConfig.
+type HostConfig struct {
+	Servers []ServerConfig `json:"servers" yaml:"servers"`
+}
+
+// ServerConfig describes a single MCP server and how the host connects to it.
+type ServerConfig struct {
+	// The name under which the server's tools are exposed. Must be unique within a host.
+	Name string `json:"name" yaml:"name"`
+	// How the host connects to the server.
+	// If empty, it is inferred from whether Command or URL is set.
+	Transport TransportKind `json:"transport,omitempty" yaml:"transport,omitempty"`
+
+	// The executable to run for stdio servers, along with its arguments,
+	// working directory and additional environment variables.
+	Command string            `json:"command,omitempty" yaml:"command,omitempty"`
+	Args    []string          `json:"args,omitempty" yaml:"args,omitempty"`
+	Cwd     string            `json:"cwd,omitempty" yaml:"cwd,omitempty"`
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+
+	// The endpoint of an HTTP server, and static headers sent with every request to it.
+	URL     string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Credentials and TLS settings for HTTP servers.
+	Auth *AuthConfig `json:"auth,omitempty" yaml:"auth,omitempty"`
+	TLS  *TLSConfig  `json:"tls,omitempty" yaml:"tls,omitempty"`
+	// Whether an http server that rejects the streamable HTTP initialize request
+	// is retried with the legacy HTTP+SSE transport. Defaults to true.
+	SSEFallback *bool `json:"sseFallback,omitempty" yaml:"sseFallback,omitempty"`
+
+	// How long to wait for the server to start and complete initialization.
+	// Zero means no limit beyond that of the context used to connect.
+	ConnectTimeout Duration `json:"connectTimeout,omitempty" yaml:"connectTimeout,omitempty"`
+	// How long listing the server's tools may take before the server is left out of a listing
+	// of all tools. Defaults to 10s.
+	ListToolsTimeout Duration `json:"listToolsTimeout,omitempty" yaml:"listToolsTimeout,omitempty"`
+	// How long a call to one of the server's tools may take before it is abandoned,
+	// and the timeouts of particular tools, by name, which take precedence.
+	// Zero means no limit beyond that of the generation.
+	CallTimeout  Duration            `json:"callTimeout,omitempty" yaml:"callTimeout,omitempty"`
+	ToolTimeouts map[string]Duration `json:"toolTimeouts,omitempty" yaml:"toolTimeouts,omitempty"`
+
+	// If set to false, the server is configured but no session is opened with it.
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// The least severe MCP logging level of the log messages the server is asked to send,
+	// such as "debug" or "warning". Defaults to "info".
+	LogLevel string `json:"logLevel,omitempty" yaml:"logLevel,omitempty"`
+
+	// Restricts which of the server's tools are offered by the host.
+	Tools ToolPolicy `json:"tools,omitzero" yaml:"tools,omitempty"`
+
+	// How the host reopens the session when it fails.
+	Restart RestartPolicy `json:"restart,omitzero" yaml:"restart,omitempty"`
+
+	// Whether the server may request completions from the host's model.
+	Sampling SamplingPolicy `json:"sampling,omitzero" yaml:"sampling,omitempty"`
+
+	// The directories the server may operate in, announced to it as MCP roots.
+	Roots []RootConfig `json:"roots,omitempty" yaml:"roots,omitempty"`
+}
+
+type TransportKind string
+
+const (
+	TransportStdio TransportKind = "stdio"
+	TransportHTTP  TransportKind = "http"
+	// The legacy HTTP+SSE transport from the 2024-11-05 protocol revision.
+	TransportSSE TransportKind = "sse"
+)
+
+// AuthConfig supplies a bearer token that is sent in the Authorization header of every request.
+// Exactly one source of the token must be given.
+type AuthConfig struct {
+	BearerToken string `json:"bearerToken,omitempty" yaml:"bearerToken,omitempty"`
+	// The name of an environment variable holding the token.
+	BearerTokenEnv string `json:"bearerTokenEnv,omitempty" yaml:"bearerTokenEnv,omitempty"`
+	// The path of a file holding the token. Surrounding whitespace is ignored.
+	BearerTokenFile string `json:"bearerTokenFile,omitempty" yaml:"bearerTokenFile,omitempty"`
+}
+
+// TLSConfig customizes how the host verifies, and authenticates itself to, an https server.
+type TLSConfig struct {
+	// A PEM bundle of certificate authorities trusted in addition to the system's.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// A PEM client certificate and its key, presented for mutual TLS.
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile  string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	// Overrides the name used to verify the server's certificate.
+	ServerName string `json:"serverName,omitempty" yaml:"serverName,omitempty"`
+	// Disables verification of the server's certificate. Only for testing.
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
+}
+
+// RestartPolicy controls how a failed session is reopened.
+// Stdio servers are restarted and HTTP sessions are reinitialized.
+type RestartPolicy struct {
+	// If true, a failed session is not reopened.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// The number of attempts to reopen a session before giving up. Zero means no limit.
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	// The delay before the first attempt, doubled after each failed attempt up to MaxBackoff.
+	// Default to 500ms and 30s.
+	InitialBackoff Duration `json:"initialBackoff,omitempty" yaml:"initialBackoff,omitempty"`
+	MaxBackoff     Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+	// How often the server is pinged. Defaults to 30s.
+	HealthCheckInterval Duration `json:"healthCheckInterval,omitempty" yaml:"healthCheckInterval,omitempty"`
+}
+
+// ToolPolicy restricts which of a server's tools are offered by the host.
+type ToolPolicy struct {
+	// If non-empty, only the tools named here are offered.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+	// The tools named here are never offered, even if they are also allowed.
+	Deny []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// SamplingPolicy controls the server's access to the host's model through MCP sampling.
+type SamplingPolicy struct {
+	// If true, the server's sampling requests are answered. Sampling is refused by default.
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// Caps the number of tokens generated for each request, overriding larger limits
+	// requested by the server. Zero means no cap.
+	MaxTokens int `json:"maxTokens,omitempty" yaml:"maxTokens,omitempty"`
+}
+
+// RootConfig is a directory that a server may operate in.
+type RootConfig struct {
+	// A file:// URI or a filesystem path. Relative paths are resolved against the host's working directory.
+	Path string `json:"path" yaml:"path"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+}
+
+// Reports whether the policy lets the host offer the tool with the given name.
+func (p *ToolPolicy) Permits(toolName string) bool {
+	if slices.Contains(p.Deny, toolName) {
+		return false
+	}
+	return len(p.Allow) == 0 || slices.Contains(p.Allow, toolName)
+}
+
+// Reports whether a rejected streamable HTTP connection should be retried over SSE.
+func (c *ServerConfig) sseFallbackEnabled() bool {
+	return c.Transport == TransportHTTP && (c.SSEFallback == nil || *c.SSEFallback)
+}
+
+// Reports whether the server should be connected to.
+func (c *ServerConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// Duration is a time.Duration written in configuration files as a string such as "1m30s".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	return d.parse(s)
+}
+
+func (d Duration) MarshalYAML() (any, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.ScalarNode || n.Tag != "!!str" {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	return d.parse(n.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ConfigError reports a problem with a host configuration,
+// locating it by line (when known) and by field path, e.g. "servers[1].url".
+type ConfigError struct {
+	Line  int
+	Field string
+	Msg   string
+}
+
+func (e *ConfigError) Error() string {
+	var bldr strings.Builder
+	if e.Line > 0 {
+		fmt.Fprintf(&bldr, "line %d: ", e.Line)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&bldr, "%s: ", e.Field)
+	}
+	bldr.WriteString(e.Msg)
+	return bldr.String()
+}
+
+type ConfigFormat int
+
+const (
+	// Detects the format from the content of the configuration.
+	FormatAuto ConfigFormat = iota
+	FormatJSON
+	FormatYAML
+	// The line-oriented `![dir][name] cmd args` and `>[name] url` format.
+	FormatLegacy
+)
+
+// Chooses a configuration format from a file's extension,
+// falling back to detection from its content.
+func FormatFromPath(path string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".conf":
+		return FormatLegacy
+	default:
+		return FormatAuto
+	}
+}
+
+// Reads and validates the host configuration stored at path.
+func LoadConfigFile(path string) (*HostConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := ParseConfig(f, FormatFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parses and validates a host configuration.
+// Problems are reported as one or more *ConfigError joined with errors.Join.
+func ParseConfig(r io.Reader, format ConfigFormat) (*HostConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FormatAuto {
+		format = detectFormat(data)
+	}
+
+	var cfg *HostConfig
+	var lines fieldLines
+	switch format {
+	case FormatLegacy:
+		cfg, lines, err = parseLegacyConfig(data)
+	case FormatJSON:
+		if err := checkJsonSyntax(data); err != nil {
+			return nil, err
+		}
+		cfg, lines, err = parseStructuredConfig(data)
+	case FormatYAML:
+		cfg, lines, err = parseStructuredConfig(data)
+	default:
+		return nil, fmt.Errorf("unknown config format %d", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := cfg.validate(); len(errs) > 0 {
+		return nil, lines.locate(errs)
+	}
+	return cfg, nil
+}
+
+// Parses and validates the configuration of a single server, written as a JSON or YAML mapping.
+func ParseServerConfig(data []byte) (*ServerConfig, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing server config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, &ConfigError{Msg: "server config is empty"}
+	}
+
+	cfg := &ServerConfig{}
+	d := &configDecoder{lines: fieldLines{}}
+	d.server(doc.Content[0], "", cfg)
+	if len(d.errs) > 0 {
+		return nil, joinConfigErrors(d.errs)
+	}
+
+	if errs := cfg.validate(""); len(errs) > 0 {
+		return nil, d.lines.locate(errs)
+	}
+	return cfg, nil
+}
+
+// Checks that the configuration is complete and consistent.
+func (c *HostConfig) Validate() error {
+	return joinConfigErrors(c.validate())
+}
+
+func (c *HostConfig) validate() []*ConfigError {
+	var errs []*ConfigError
+	seen := make(map[string]bool)
+	for i := range c.Servers {
+		path := fmt.Sprintf("servers[%d]", i)
+		errs = append(errs, c.Servers[i].validate(path)...)
+
+		name := c.Servers[i].Name
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			errs = append(errs, &ConfigError{Field: path + ".name", Msg: fmt.Sprintf("server name conflict: %s", name)})
+		}
+		seen[name] = true
+	}
+	return errs
+}
+
+// Checks that the server's configuration is complete and consistent.
+// A missing transport is filled in when it can be inferred.
+func (c *ServerConfig) Validate() error {
+	return joinConfigErrors(c.validate(""))
+}
+
+var serverNameRegex = regexp.MustCompile(`^\w[\w-]*$`)
+
+func (c *ServerConfig) validate(path string) []*ConfigError {
+	var errs []*ConfigError
+	fail := func(field string, format string, args ...any) {
+		errs = append(errs, &ConfigError{Field: joinField(path, field), Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Name == "" {
+		fail("name", "is required")
+	} else if !serverNameRegex.MatchString(c.Name) {
+		fail("name", "must contain only letters, digits, '_' and '-', and must not start with '-'")
+	}
+
+	if c.Transport == "" {
+		if c.Command != "" && c.URL == "" {
+			c.Transport = TransportStdio
+		} else if c.URL != "" && c.Command == "" {
+			c.Transport = TransportHTTP
+		} else {
+			fail("transport", "is required when it cannot be inferred from exactly one of command or url")
+			return errs
+		}
+	}
+
+	switch c.Transport {
+	case TransportStdio:
+		if c.Command == "" {
+			fail("command", "is required for the %s transport", c.Transport)
+		}
+		if c.URL != "" {
+			fail("url", "is not used by the %s transport", c.Transport)
+		}
+		if len(c.Headers) > 0 {
+			fail("headers", "are not used by the %s transport", c.Transport)
+		}
+		if c.Auth != nil {
+			fail("auth", "is not used by the %s transport", c.Transport)
+		}
+		if c.TLS != nil {
+			fail("tls", "is not used by the %s transport", c.Transport)
+		}
+		for key := range c.Env {
+			if key == "" || strings.ContainsAny(key, "=\x00") {
+				fail("env", "invalid variable name %q", key)
+			}
+		}
+	case TransportHTTP, TransportSSE:
+		if c.URL == "" {
+			fail("url", "is required for the %s transport", c.Transport)
+		} else if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("url", "must be an absolute http:// or https:// URL")
+		}
+		if c.Command != "" || len(c.Args) > 0 || c.Cwd != "" || len(c.Env) > 0 {
+			fail("command", "command, args, cwd and env are not used by the %s transport", c.Transport)
+		}
+		if c.Auth != nil {
+			sources := 0
+			for _, source := range []string{c.Auth.BearerToken, c.Auth.BearerTokenEnv, c.Auth.BearerTokenFile} {
+				if source != "" {
+					sources++
+				}
+			}
+			if sources != 1 {
+				fail("auth", "exactly one of bearerToken, bearerTokenEnv or bearerTokenFile must be set")
+			}
+		}
+		if c.TLS != nil {
+			if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+				fail("tls", "certFile and keyFile must be set together")
+			}
+			if u, err := url.Parse(c.URL); err == nil && u.Scheme != "https" {
+				fail("tls", "requires an https:// url")
+			}
+		}
+	default:
+		fail("transport", "unknown transport %q", c.Transport)
+	}
+
+	if c.SSEFallback != nil && c.Transport != TransportHTTP {
+		fail("sseFallback", "is only used by the %s transport", TransportHTTP)
+	}
+
+	if c.ConnectTimeout < 0 {
+		fail("connectTimeout", "must not be negative")
+	}
+	if c.ListToolsTimeout < 0 {
+		fail("listToolsTimeout", "must not be negative")
+	}
+	if c.CallTimeout < 0 {
+		fail("callTimeout", "must not be negative")
+	}
+	for _, tool := range slices.Sorted(maps.Keys(c.ToolTimeouts)) {
+		if c.ToolTimeouts[tool] < 0 {
+			fail("toolTimeouts."+tool, "must not be negative")
+		}
+	}
+
+	if c.LogLevel != "" && !isLogLevel(c.LogLevel) {
+		fail("logLevel", "unknown logging level %q", c.LogLevel)
+	}
+
+	if c.Restart.MaxAttempts < 0 {
+		fail("restart.maxAttempts", "must not be negative")
+	}
+	if c.Restart.InitialBackoff < 0 || c.Restart.MaxBackoff < 0 || c.Restart.HealthCheckInterval < 0 {
+		fail("restart", "durations must not be negative")
+	}
+
+	if c.Sampling.MaxTokens < 0 {
+		fail("sampling.maxTokens", "must not be negative")
+	}
+
+	for i, root := range c.Roots {
+		if root.Path == "" {
+			fail(fmt.Sprintf("roots[%d].path", i), "is required")
+		} else if u, err := url.Parse(root.Path); err == nil && u.Scheme != "" && u.Scheme != "file" && filepath.VolumeName(root.Path) == "" {
+			fail(fmt.Sprintf("roots[%d].path", i), "must be a filesystem path or a file:// URI")
+		}
+	}
+
+	for _, name := range slices.Concat(c.Tools.Allow, c.Tools.Deny) {
+		if name == "" {
+			fail("tools", "tool names must not be empty")
+			break
+		}
+	}
+
+	return errs
+}
+
+func joinField(path string, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func joinConfigErrors(errs []*ConfigError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	joined := make([]error, len(errs))
+	for i, err := range errs {
+		joined[i] = err
+	}
+	return errors.Join(joined...)
+}
+
+// fieldLines maps field paths to the line on which they are written.
+type fieldLines map[string]int
+
+// Fills in the line of every error from the position of its field,
+// or of the nearest enclosing field whose position is known.
+func (fl fieldLines) locate(errs []*ConfigError) error {
+	for _, err := range errs {
+		if err.Line != 0 {
+			continue
+		}
+		for field := err.Field; field != ""; field = parentField(field) {
+			if line, ok := fl[field]; ok {
+				err.Line = line
+				break
+			}
+		}
+	}
+	return joinConfigErrors(errs)
+}
+
+func parentField(field string) string {
+	i := strings.LastIndexAny(field, ".[")
+	if i < 0 {
+		return ""
+	}
+	return field[:i]
+}
+
+func detectFormat(data []byte) ConfigFormat {
+	trimmed := bytes.TrimLeftFunc(data, unicode.IsSpace)
+	if len(trimmed) == 0 {
+		return FormatYAML
+	}
+	switch trimmed[0] {
+	case '{':
+		return FormatJSON
+	case '!', '>':
+		return FormatLegacy
+	default:
+		return FormatYAML
+	}
+}
+
+func checkJsonSyntax(data []byte) error {
+	var v any
+	err := json.Unmarshal(data, &v)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &ConfigError{Line: lineOfOffset(data, syntaxErr.Offset), Msg: syntaxErr.Error()}
+	}
+	return err
+}
+
+func lineOfOffset(data []byte, offset int64) int {
+	offset = min(offset, int64(len(data)))
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// JSON documents are parsed as YAML, of which JSON is a subset,
+// so that every field can be located by line.
+func parseStructuredConfig(data []byte) (*HostConfig, fieldLines, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing config: %w", err)
+	}
+
+	cfg := &HostConfig{}
+	d := &configDecoder{lines: fieldLines{}}
+	if len(doc.Content) == 0 {
+		return cfg, d.lines, nil
+	}
+
+	d.mapping(doc.Content[0], "", map[string]func(*yaml.Node, string){
+		"servers": func(n *yaml.Node, path string) {
+			if n.Kind != yaml.SequenceNode {
+				d.fail(n.Line, path, "expected a list of servers")
+				return
+			}
+			cfg.Servers = make([]ServerConfig, len(n.Content))
+			for i, serverNode := range n.Content {
+				d.server(serverNode, fmt.Sprintf("%s[%d]", path, i), &cfg.Servers[i])
+			}
+		},
+	})
+
+	if len(d.errs) > 0 {
+		return nil, nil, joinConfigErrors(d.errs)
+	}
+	return cfg, d.lines, nil
+}
+
+type configDecoder struct {
+	lines fieldLines
+	errs  []*ConfigError
+}
+
+func (d *configDecoder) fail(line int, field string, format string, args ...any) {
+	d.errs = append(d.errs, &ConfigError{Line: line, Field: field, Msg: fmt.Sprintf(format, args...)})
+}
+
+// Decodes a mapping node by handing each of its values to the handler registered for its key.
+// Unknown keys are reported as errors.
+func (d *configDecoder) mapping(n *yaml.Node, path string, handlers map[string]func(*yaml.Node, string)) {
+	d.lines[path] = n.Line
+	if n.Kind != yaml.MappingNode {
+		d.fail(n.Line, path, "expected a mapping")
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		field := joinField(path, key.Value)
+		handler, ok := handlers[key.Value]
+		if !ok {
+			d.fail(key.Line, field, "unknown field")
+			continue
+		}
+		d.lines[field] = key.Line
+		handler(value, field)
+	}
+}
+
+// Returns a handler that decodes a value node into dst.
+func (d *configDecoder) into(dst any) func(*yaml.Node, string) {
+	return func(n *yaml.Node, field string) {
+		if err := n.Decode(dst); err != nil {
+			d.fail(n.Line, field, "%s", decodeErrorMessage(err))
+		}
+	}
+}
+
+func (d *configDecoder) server(n *yaml.Node, path string, cfg *ServerConfig) {
+	d.mapping(n, path, map[string]func(*yaml.Node, string){
+		"name":      d.into(&cfg.Name),
+		"transport": d.into(&cfg.Transport),
+		"command":   d.into(&cfg.Command),
+		"args":      d.into(&cfg.Args),
+		"cwd":       d.into(&cfg.Cwd),
+		"env":       d.into(&cfg.Env),
+		"url":       d.into(&cfg.URL),
+		"headers":   d.into(&cfg.Headers),
+		"auth": func(n *yaml.Node, path string) {
+			cfg.Auth = &AuthConfig{}
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"bearerToken":     d.into(&cfg.Auth.BearerToken),
+				"bearerTokenEnv":  d.into(&cfg.Auth.BearerTokenEnv),
+				"bearerTokenFile": d.into(&cfg.Auth.BearerTokenFile),
+			})
+		},
+		"sseFallback": d.into(&cfg.SSEFallback),
+		"tls": func(n *yaml.Node, path string) {
+			cfg.TLS = &TLSConfig{}
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"caFile":             d.into(&cfg.TLS.CAFile),
+				"certFile":           d.into(&cfg.TLS.CertFile),
+				"keyFile":            d.into(&cfg.TLS.KeyFile),
+				"serverName":         d.into(&cfg.TLS.ServerName),
+				"insecureSkipVerify": d.into(&cfg.TLS.InsecureSkipVerify),
+			})
+		},
+		"connectTimeout":   d.into(&cfg.ConnectTimeout),
+		"listToolsTimeout": d.into(&cfg.ListToolsTimeout),
+		"callTimeout":      d.into(&cfg.CallTimeout),
+		"toolTimeouts":     d.into(&cfg.ToolTimeouts),
+		"enabled":          d.into(&cfg.Enabled),
+		"logLevel":         d.into(&cfg.LogLevel),
+		"restart": func(n *yaml.Node, path string) {
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"disabled":            d.into(&cfg.Restart.Disabled),
+				"maxAttempts":         d.into(&cfg.Restart.MaxAttempts),
+				"initialBackoff":      d.into(&cfg.Restart.InitialBackoff),
+				"maxBackoff":          d.into(&cfg.Restart.MaxBackoff),
+				"healthCheckInterval": d.into(&cfg.Restart.HealthCheckInterval),
+			})
+		},
+		"tools": func(n *yaml.Node, path string) {
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"allow": d.into(&cfg.Tools.Allow),
+				"deny":  d.into(&cfg.Tools.Deny),
+			})
+		},
+		"roots": func(n *yaml.Node, path string) {
+			if n.Kind != yaml.SequenceNode {
+				d.fail(n.Line, path, "expected a list of roots")
+				return
+			}
+			cfg.Roots = make([]RootConfig, len(n.Content))
+			for i, item := range n.Content {
+				root := &cfg.Roots[i]
+				d.mapping(item, fmt.Sprintf("%s[%d]", path, i), map[string]func(*yaml.Node, string){
+					"path": d.into(&root.Path),
+					"name": d.into(&root.Name),
+				})
+			}
+		},
+		"sampling": func(n *yaml.Node, path string) {
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"enabled":   d.into(&cfg.Sampling.Enabled),
+				"maxTokens": d.into(&cfg.Sampling.MaxTokens),
+			})
+		},
+	})
+}
+
+var yamlLinePrefixRegex = regexp.MustCompile(`^line \d+: `)
+
+func decodeErrorMessage(err error) string {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		return yamlLinePrefixRegex.ReplaceAllString(typeErr.Errors[0], "")
+	}
+	return err.Error()
+}
+
+var stdioRegex = regexp.MustCompile(`^!\[([^\]]+)\](\[(\w[\w\d-_]*)\])?\s*(\S+)\s*(.*)$`)
+var httpRegex = regexp.MustCompile(`^>\[(\w[\w\d-_]*)\]\s*(https?://.+)$`)
+
+// Parses the legacy line-oriented configuration format, in which each non-blank line is either
+// `![dir][name] command args...` for a stdio server (the name defaults to the command) or
+// `>[name] http(s)://...` for a streamable HTTP server.
+func parseLegacyConfig(data []byte) (*HostConfig, fieldLines, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	cfg := &HostConfig{}
+	lines := fieldLines{}
+	var errs []*ConfigError
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		server, err := serverConfigFromLine(line)
+		if err != nil {
+			errs = append(errs, &ConfigError{Line: lineNumber, Msg: err.Error()})
+			continue
+		}
+		lines[fmt.Sprintf("servers[%d]", len(cfg.Servers))] = lineNumber
+		cfg.Servers = append(cfg.Servers, server)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, joinConfigErrors(errs)
+	}
+	return cfg, lines, nil
+}
+
+func serverConfigFromLine(line string) (ServerConfig, error) {
+	if matches := stdioRegex.FindStringSubmatch(line); len(matches) > 0 {
+		command := matches[len(matches)-2]
+		sessionName := legacyServerName(command)
+		if matches[3] != "" {
+			sessionName = matches[3]
+		}
+
+		return ServerConfig{
+			Name:      sessionName,
+			Transport: TransportStdio,
+			Command:   command,
+			Args:      splitIntoWords(matches[len(matches)-1]),
+			Cwd:       matches[1],
+		}, nil
+
+	} else if matches := httpRegex.FindStringSubmatch(line); len(matches) > 0 {
+		return ServerConfig{
+			Name:      matches[1],
+			Transport: TransportHTTP,
+			URL:       strings.TrimSpace(matches[2]),
+		}, nil
+	}
+
+	return ServerConfig{}, fmt.Errorf("invalid line in config: %s", line)
+}
+
+var invalidServerNameChars = regexp.MustCompile(`[^\w-]`)
+
+// Names a legacy stdio server that was given no name after its command, such as "mcp-server"
+// for "/usr/bin/mcp-server", replacing the characters that server names may not contain.
+func legacyServerName(command string) string {
+	name := invalidServerNameChars.ReplaceAllString(filepath.Base(command), "_")
+	if strings.HasPrefix(name, "-") {
+		name = "_" + name[1:]
+	}
+	return name
+}
+
+var whiteSpaceRegex = regexp.MustCompile(`\s+`)
+
+func splitIntoWords(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	return whiteSpaceRegex.Split(line, -1)
+}
@@ -0,0 +1,160 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseYamlConfig(t *testing.T) {
+	config := `
+# Servers used by the tests
+servers:
+  - name: greeter
+    command: go
+    args: [run, greetings.go]
+    cwd: ../../test_servers/greetings
+    env:
+      GREETING: hi
+    connectTimeout: 30s
+    tools:
+      deny: [greet]
+  - name: math
+    transport: http
+    url: http://127.0.0.1:8080
+    enabled: false
+`
+	cfg, err := ParseConfig(strings.NewReader(config), FormatAuto)
+	if err != nil {
+		t.Fatalf("could not parse config: %s", err)
+	}
+
+	if len(cfg.Servers) != 2 {
+		t.Fatalf("expected 2 server(s) but found %d", len(cfg.Servers))
+	}
+
+	greeter := cfg.Servers[0]
+	if greeter.Transport != TransportStdio {
+		t.Errorf("expected the transport to be inferred as %q but was %q", TransportStdio, greeter.Transport)
+	}
+	if len(greeter.Args) != 2 || greeter.Env["GREETING"] != "hi" || greeter.Cwd != "../../test_servers/greetings" {
+		t.Errorf("greeter not parsed properly: %+v", greeter)
+	}
+	if time.Duration(greeter.ConnectTimeout) != 30*time.Second {
+		t.Errorf("expected a connect timeout of 30s but found %s", greeter.ConnectTimeout)
+	}
+	if greeter.Tools.Permits("greet") {
+		t.Errorf("expected the tool policy to deny 'greet'")
+	}
+
+	if cfg.Servers[1].IsEnabled() {
+		t.Errorf("expected math to be disabled")
+	}
+}
+
+func TestParseJsonConfig(t *testing.T) {
+	config := `{
+	"servers": [
+		{"name": "math", "url": "https://example.com/mcp", "headers": {"X-Team": "tools"}}
+	]
+}`
+	cfg, err := ParseConfig(strings.NewReader(config), FormatAuto)
+	if err != nil {
+		t.Fatalf("could not parse config: %s", err)
+	}
+	if len(cfg.Servers) != 1 || cfg.Servers[0].Transport != TransportHTTP || cfg.Servers[0].Headers["X-Team"] != "tools" {
+		t.Fatalf("math not parsed properly: %+v", cfg.Servers)
+	}
+}
+
+func TestParseLegacyConfig(t *testing.T) {
+	config := "![../../test_servers/greetings][greeter] go run greetings.go\n\n>[math] http://127.0.0.1:8080"
+	cfg, err := ParseConfig(strings.NewReader(config), FormatAuto)
+	if err != nil {
+		t.Fatalf("could not parse config: %s", err)
+	}
+	if len(cfg.Servers) != 2 {
+		t.Fatalf("expected 2 server(s) but found %d", len(cfg.Servers))
+	}
+	if s := cfg.Servers[0]; s.Name != "greeter" || s.Command != "go" || s.Cwd != "../../test_servers/greetings" || len(s.Args) != 2 {
+		t.Errorf("greeter not parsed properly: %+v", s)
+	}
+	if s := cfg.Servers[1]; s.Name != "math" || s.URL != "http://127.0.0.1:8080" {
+		t.Errorf("math not parsed properly: %+v", s)
+	}
+}
+
+func TestParseLegacyConfigWithoutNames(t *testing.T) {
+	config := "![.] ./server\n![/opt] /usr/bin/mcp-server.py --stdio"
+	cfg, err := ParseConfig(strings.NewReader(config), FormatAuto)
+	if err != nil {
+		t.Fatalf("could not parse config: %s", err)
+	}
+	if len(cfg.Servers) != 2 || cfg.Servers[0].Name != "server" || cfg.Servers[1].Name != "mcp-server_py" {
+		t.Fatalf("expected the servers to be named after their commands but found %+v", cfg.Servers)
+	}
+	if s := cfg.Servers[1]; s.Command != "/usr/bin/mcp-server.py" || len(s.Args) != 1 {
+		t.Errorf("mcp-server not parsed properly: %+v", s)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		format ConfigFormat
+		line   int
+		field  string
+	}{
+		{"unknown field", "servers:\n  - name: a\n    command: go\n    comand: go\n", FormatYAML, 4, "servers[0].comand"},
+		{"wrong type", "servers:\n  - name: a\n    command: go\n    args: run\n", FormatYAML, 4, "servers[0].args"},
+		{"unknown transport", "servers:\n  - name: a\n    transport: ftp\n", FormatYAML, 3, "servers[0].transport"},
+		{"missing url", "{\n  \"servers\": [\n    {\"name\": \"a\",\n     \"transport\": \"http\"}\n  ]\n}", FormatJSON, 3, "servers[0].url"},
+		{"duplicate name", "servers:\n  - {name: a, command: go}\n  - {name: a, command: go}\n", FormatYAML, 3, "servers[1].name"},
+		{"bad duration", "servers:\n  - name: a\n    command: go\n    connectTimeout: soon\n", FormatYAML, 4, "servers[0].connectTimeout"},
+		{"bad root", "servers:\n  - name: a\n    command: go\n    roots:\n      - path: /srv\n      - path: https://example.com\n", FormatYAML, 6, "servers[0].roots[1].path"},
+		{"negative tool timeout", "servers:\n  - name: a\n    command: go\n    toolTimeouts:\n      greet: -1s\n", FormatYAML, 4, "servers[0].toolTimeouts.greet"},
+		{"legacy line", "![.][a] go run a.go\n>[b] ftp://example.com", FormatLegacy, 2, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseConfig(strings.NewReader(test.config), test.format)
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Fatalf("expected a *ConfigError but got %v", err)
+			}
+			if configErr.Line != test.line || configErr.Field != test.field {
+				t.Errorf("expected an error at line %d, field %q but got %q", test.line, test.field, configErr)
+			}
+		})
+	}
+}
+
+func TestToolPolicy(t *testing.T) {
+	ctx := context.Background()
+	host, _ := NewMcpHost(nil)
+
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader(`
+servers:
+  - name: greeter
+    command: go
+    args: [run, greetings.go]
+    cwd: ../../test_servers/greetings
+    tools:
+      deny: [greet]
+`), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	tools, err := host.ListToolsOnServer(ctx, "greeter")
+	if err != nil {
+		t.Fatalf("could not list tools: %s", err)
+	}
+	if len(tools) != 0 {
+		t.Errorf("expected the denied tool to be hidden but found %v", tools)
+	}
+}
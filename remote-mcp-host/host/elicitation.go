@@ -0,0 +1,77 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Elicitor asks the user on whose behalf tools are called for input requested by a server.
+type Elicitor interface {
+	Elicit(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error)
+}
+
+// Passes an elicitation request from a server to the elicitor of the tool calls in flight on that server.
+// MCP does not say which request an elicitation belongs to, so if several clients with elicitors are
+// calling tools on the server at once, the elicitation is declined rather than shown to a user it may
+// not concern.
+func (h *McpHost) elicit(ctx context.Context, req *mcp.ElicitRequest) (*mcp.ElicitResult, error) {
+	server, ok := h.serverOfSession(req.Session)
+	if !ok {
+		return nil, fmt.Errorf("elicitation is not available to this session")
+	}
+	elicitor, ambiguous := server.elicitor()
+	if ambiguous {
+		log.Printf("Declining an elicitation from server '%s', which is serving several users at once", server.config.Name)
+		return &mcp.ElicitResult{Action: "decline"}, nil
+	}
+	if elicitor == nil {
+		return nil, fmt.Errorf("no user is available to answer server '%s'", server.config.Name)
+	}
+	return elicitor.Elicit(ctx, server.config.Name, req.Params)
+}
+
+// clientElicitor is the elicitor of one client, shared by the copies of the client.
+// Tool calls are attributed to clients by its identity, since elicitors need not be comparable.
+type clientElicitor struct {
+	Elicitor
+}
+
+// Makes the client's elicitor available to the server until the returned function is called.
+func (s *serverSession) addElicitor(client *clientElicitor) (remove func()) {
+	handle := &elicitorHandle{client}
+	s.mu.Lock()
+	s.elicitors = append(s.elicitors, handle)
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.elicitors = slices.DeleteFunc(s.elicitors, func(e *elicitorHandle) bool { return e == handle })
+		s.mu.Unlock()
+	}
+}
+
+// Gets the elicitor of the tool calls in flight on the server,
+// or reports that the calls were made by different clients.
+func (s *serverSession) elicitor() (elicitor Elicitor, ambiguous bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var client *clientElicitor
+	for _, handle := range s.elicitors {
+		if client != nil && handle.client != client {
+			return nil, true
+		}
+		client = handle.client
+	}
+	if client == nil {
+		return nil, false
+	}
+	return client.Elicitor, false
+}
+
+// elicitorHandle distinguishes the registrations of a client's calls.
+type elicitorHandle struct {
+	client *clientElicitor
+}
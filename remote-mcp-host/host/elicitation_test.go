@@ -0,0 +1,66 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type namedElicitor struct {
+	name string
+}
+
+func (e *namedElicitor) Elicit(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+	return &mcp.ElicitResult{Action: "accept", Content: map[string]any{"user": e.name}}, nil
+}
+
+// elicitorFunc is an elicitor that cannot be compared.
+type elicitorFunc func(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error)
+
+func (f elicitorFunc) Elicit(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+	return f(ctx, serverName, params)
+}
+
+func TestElicitorIsNotGuessed(t *testing.T) {
+	server := &serverSession{}
+	ada, grace := &clientElicitor{&namedElicitor{"ada"}}, &clientElicitor{&namedElicitor{"grace"}}
+
+	if elicitor, ambiguous := server.elicitor(); elicitor != nil || ambiguous {
+		t.Errorf("expected no elicitor without calls in flight but found %v", elicitor)
+	}
+
+	// Calls made by the same client share their elicitor.
+	removeFirst := server.addElicitor(ada)
+	removeSecond := server.addElicitor(ada)
+	if elicitor, ambiguous := server.elicitor(); elicitor != ada.Elicitor || ambiguous {
+		t.Errorf("expected the elicitor of the calls in flight but found %v", elicitor)
+	}
+
+	removeOther := server.addElicitor(grace)
+	if _, ambiguous := server.elicitor(); !ambiguous {
+		t.Errorf("expected the elicitor to be ambiguous while calls are made by two clients")
+	}
+
+	removeFirst()
+	removeSecond()
+	if elicitor, ambiguous := server.elicitor(); elicitor != grace.Elicitor || ambiguous {
+		t.Errorf("expected the remaining elicitor once the other calls finished but found %v", elicitor)
+	}
+	removeOther()
+}
+
+func TestElicitorsNeedNotBeComparable(t *testing.T) {
+	server := &serverSession{}
+	var answer elicitorFunc = func(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+		return &mcp.ElicitResult{Action: "decline"}, nil
+	}
+
+	first := server.addElicitor(&clientElicitor{answer})
+	defer first()
+	second := server.addElicitor(&clientElicitor{answer})
+	defer second()
+	if _, ambiguous := server.elicitor(); !ambiguous {
+		t.Errorf("expected the calls of two clients to be told apart")
+	}
+}
@@ -0,0 +1,169 @@
+package host
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultServerLogLimit = 500
+	defaultLogLevel       = "info"
+)
+
+// The logging levels of MCP, from least to most severe, and the levels at which they are logged by the host.
+var mcpLogLevels = []struct {
+	name  mcp.LoggingLevel
+	level slog.Level
+}{
+	{"debug", slog.LevelDebug},
+	{"info", slog.LevelInfo},
+	{"notice", slog.LevelInfo},
+	{"warning", slog.LevelWarn},
+	{"error", slog.LevelError},
+	{"critical", slog.LevelError},
+	{"alert", slog.LevelError},
+	{"emergency", slog.LevelError},
+}
+
+func isLogLevel(level string) bool {
+	for _, l := range mcpLogLevels {
+		if string(l.name) == level {
+			return true
+		}
+	}
+	return false
+}
+
+func slogLevel(level mcp.LoggingLevel) slog.Level {
+	for _, l := range mcpLogLevels {
+		if l.name == level {
+			return l.level
+		}
+	}
+	return slog.LevelInfo
+}
+
+// serverLogs keeps the most recent log entries of a server in a ring buffer
+// and writes every entry through the host's logger.
+type serverLogs struct {
+	serverName string
+
+	mu      sync.Mutex
+	entries []api.ServerLogEntry
+	// The index of the oldest entry once the buffer is full.
+	start int
+}
+
+func newServerLogs(serverName string, limit int) *serverLogs {
+	if limit <= 0 {
+		limit = defaultServerLogLimit
+	}
+	return &serverLogs{serverName: serverName, entries: make([]api.ServerLogEntry, 0, limit)}
+}
+
+func (l *serverLogs) add(entry api.ServerLogEntry) {
+	attrs := []any{"server", l.serverName, "source", entry.Source}
+	if entry.Logger != "" {
+		attrs = append(attrs, "logger", entry.Logger)
+	}
+	slog.Log(context.Background(), slogLevel(mcp.LoggingLevel(entry.Level)), entry.Message, attrs...)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) < cap(l.entries) {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.start] = entry
+	l.start = (l.start + 1) % len(l.entries)
+}
+
+// Gets the entries from oldest to newest.
+func (l *serverLogs) list() []api.ServerLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entries := make([]api.ServerLogEntry, 0, len(l.entries))
+	entries = append(entries, l.entries[l.start:]...)
+	return append(entries, l.entries[:l.start]...)
+}
+
+// Records the lines that the command writes to its standard error.
+// The returned file must be closed once the command has started, so that the
+// lines stop being read when the command and any processes it spawned exit.
+func (l *serverLogs) captureStderr(cmd *exec.Cmd) (*os.File, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("capturing the standard error of server '%s': %w", l.serverName, err)
+	}
+	cmd.Stderr = w
+	go func() {
+		defer r.Close()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			l.add(api.ServerLogEntry{
+				Time:    time.Now(),
+				Source:  "stderr",
+				Level:   defaultLogLevel,
+				Message: scanner.Text(),
+			})
+		}
+	}()
+	return w, nil
+}
+
+// Asks the server to send log messages at or above the level in its configuration.
+func setLogLevel(ctx context.Context, session *mcp.ClientSession, cfg *ServerConfig) error {
+	if session.InitializeResult().Capabilities.Logging == nil {
+		return nil
+	}
+	level := cfg.LogLevel
+	if level == "" {
+		level = defaultLogLevel
+	}
+	if err := session.SetLoggingLevel(ctx, &mcp.SetLoggingLevelParams{Level: mcp.LoggingLevel(level)}); err != nil {
+		return fmt.Errorf("setting the log level of server '%s': %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// Records a log message sent by a server.
+func (h *McpHost) loggingMessage(ctx context.Context, req *mcp.LoggingMessageRequest) {
+	server, ok := h.serverOfSession(req.Session)
+	if !ok {
+		return
+	}
+	message, ok := req.Params.Data.(string)
+	if !ok {
+		data, err := json.Marshal(req.Params.Data)
+		if err != nil {
+			return
+		}
+		message = string(data)
+	}
+	server.logs.add(api.ServerLogEntry{
+		Time:    time.Now(),
+		Source:  "mcp",
+		Level:   string(req.Params.Level),
+		Logger:  req.Params.Logger,
+		Message: message,
+	})
+}
+
+// Gets the most recent log entries of a server, from oldest to newest.
+func (h *McpHost) GetServerLogs(serverName string) ([]api.ServerLogEntry, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	return server.logs.list(), nil
+}
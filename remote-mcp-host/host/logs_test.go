@@ -0,0 +1,76 @@
+package host
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func logMessages(logs []api.ServerLogEntry) []string {
+	var messages []string
+	for _, entry := range logs {
+		messages = append(messages, entry.Source+" "+entry.Level+" "+entry.Message)
+	}
+	return messages
+}
+
+func TestServerLogs(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:     "logging",
+		Command:  "go",
+		Args:     []string{"run", "logging.go"},
+		Cwd:      "../../test_servers/logging",
+		LogLevel: "notice",
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	client, _ := host.GetClient(ctx, nil)
+	for _, level := range []string{"info", "warning"} {
+		res, err := client.CallTool(ctx, &agent.ServerToolRequest{
+			ServerName:     "logging",
+			CallToolParams: mcp.CallToolParams{Name: "log", Arguments: map[string]any{"message": "at " + level, "level": level}},
+		})
+		if err != nil || res.Output.IsError {
+			t.Fatalf("could not log: %v %v", err, res)
+		}
+	}
+
+	var messages []string
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(messages, "mcp warning at warning") && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		logs, _ := host.GetServerLogs("logging")
+		messages = logMessages(logs)
+	}
+	if !slices.Contains(messages, "stderr info starting") || !slices.Contains(messages, "mcp warning at warning") {
+		t.Errorf("expected the server's standard error and log messages but found %q", messages)
+	}
+	if slices.Contains(messages, "mcp info at info") {
+		t.Errorf("expected messages below the configured level not to be sent but found %q", messages)
+	}
+
+	if _, err := host.GetServerLogs("missing"); err == nil {
+		t.Errorf("expected getting the logs of an unknown server to fail")
+	}
+}
+
+func TestServerLogsAreBounded(t *testing.T) {
+	logs := newServerLogs("test", 3)
+	for _, message := range []string{"a", "b", "c", "d", "e"} {
+		logs.add(api.ServerLogEntry{Source: "mcp", Level: "debug", Message: message})
+	}
+	if messages := logMessages(logs.list()); !slices.Equal(messages, []string{"mcp debug c", "mcp debug d", "mcp debug e"}) {
+		t.Errorf("expected only the most recent entries, oldest first, but found %q", messages)
+	}
+}
@@ -1,31 +1,52 @@
 package host
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"iter"
 	"log"
-	"os/exec"
-	"regexp"
+	"maps"
 	"slices"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func NewMcpHost(_ *McpHostOptions) (McpHost, error) {
+func NewMcpHost(opts *McpHostOptions) (McpHost, error) {
+	if opts == nil {
+		opts = &McpHostOptions{}
+	}
 
-	client := mcp.NewClient(&mcp.Implementation{Name: "Remote MCP Host Client", Version: "0.1.0"}, nil)
+	host := McpHost{
+		mu:       &sync.RWMutex{},
+		sessions: make(map[string]*serverSession),
+		opts:     opts,
+		reloadMu: &sync.Mutex{},
+		applied:  make(map[string]bool),
 
-	return McpHost{
-		sessions:      make(map[string]*mcp.ClientSession),
-		defaultClient: client,
-		opts:          nil,
-	}, nil
+		progressMu:    &sync.Mutex{},
+		progressCalls: make(map[string]*progressCall),
+	}
+
+	return host, nil
+}
+
+// Makes a client for sessions with servers, handling their requests with the host.
+func (h *McpHost) newClient() *mcp.Client {
+	opts := &mcp.ClientOptions{
+		ElicitationHandler:          h.elicit,
+		ToolListChangedHandler:      h.toolListChanged,
+		ProgressNotificationHandler: h.progressNotification,
+		LoggingMessageHandler:       h.loggingMessage,
+	}
+	if h.opts.Sampler != nil {
+		opts.CreateMessageHandler = h.createMessage
+	}
+	return mcp.NewClient(&mcp.Implementation{Name: "Remote MCP Host Client", Version: "0.1.0"}, opts)
 }
 
 func (h *McpHost) GetClient(ctx context.Context, opts *ClientOptions) (agent.McpClient, error) {
@@ -34,95 +55,255 @@ func (h *McpHost) GetClient(ctx context.Context, opts *ClientOptions) (agent.Mcp
 	}
 
 	toolConfigs := make(map[api.ToolId]api.ToolConfig)
+	toolTimeouts := make(map[api.ToolId]time.Duration)
 
 	for _, cfg := range opts.ToolConfigs {
 		toolConfigs[cfg.ToolId] = *cfg
+		if cfg.Timeout == "" {
+			continue
+		}
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout '%s' for tool '%s' on server '%s'", cfg.Timeout, cfg.ToolId.Name, cfg.ToolId.ServerName)
+		}
+		toolTimeouts[cfg.ToolId] = timeout
+	}
+
+	var elicitor *clientElicitor
+	if opts.Elicitor != nil {
+		elicitor = &clientElicitor{opts.Elicitor}
 	}
 
 	return HostMcpClient{
 		host:                   h,
 		onlyUseConfiguredTools: opts.OnlyUseConfiguredTools,
 		toolConfigs:            toolConfigs,
+		toolTimeouts:           toolTimeouts,
+		elicitor:               elicitor,
+		progress:               opts.Progress,
 	}, nil
 }
 
 // Opens MCP sessions with servers for this host.
-// If a client is not specified, the host's default client is used.
+// The config may be in any format understood by ParseConfig.
+// If a client is not specified, each server is given a client of its own.
 func (h *McpHost) AddSessionsFromConfig(ctx context.Context, config io.Reader, client *mcp.Client) error {
-	if client == nil {
-		client = h.defaultClient
-	}
-	sessions, err := loadSessionsFromConfig(client, ctx, config)
+	cfg, err := ParseConfig(config, FormatAuto)
 	if err != nil {
 		return err
 	}
+	return h.AddServers(ctx, cfg, client)
+}
 
-	for name, session := range sessions {
+// Opens MCP sessions with every enabled server in a host configuration.
+// Either all of the sessions are added or, if any fails to open, none are.
+// If a client is not specified, each server is given a client of its own;
+// servers with roots must be given their own, since a client announces its roots to all of its sessions.
+func (h *McpHost) AddServers(ctx context.Context, cfg *HostConfig, client *mcp.Client) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	h.mu.RLock()
+	for _, serverCfg := range cfg.Servers {
+		if _, ok := h.sessions[serverCfg.Name]; ok {
+			h.mu.RUnlock()
+			return fmt.Errorf("server name conflict: %s", serverCfg.Name)
+		}
+	}
+	h.mu.RUnlock()
+
+	sessions := make(map[string]*serverSession)
+	for _, serverCfg := range cfg.Servers {
+		if !serverCfg.IsEnabled() {
+			continue
+		}
+		serverClient, err := h.clientFor(&serverCfg, client)
+		if err == nil {
+			logs := newServerLogs(serverCfg.Name, h.opts.ServerLogLimit)
+			var session *mcp.ClientSession
+			if session, err = connectServer(ctx, serverClient, &serverCfg, logs); err == nil {
+				sessions[serverCfg.Name] = newServerSession(serverCfg, serverClient, session, logs)
+				sessions[serverCfg.Name].sharedClient = client != nil
+			}
+		}
+		if err != nil {
+			for _, opened := range sessions {
+				opened.close()
+			}
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Another server with a conflicting name may have been added while connecting.
+	for name := range sessions {
 		if _, ok := h.sessions[name]; ok {
+			for _, opened := range sessions {
+				opened.close()
+			}
 			return fmt.Errorf("server name conflict: %s", name)
 		}
+	}
+	for name, session := range sessions {
 		h.sessions[name] = session
+		go session.supervise()
 	}
 	return nil
 }
 
+// Closes the session with a server and forgets the server.
+// New requests to the server fail immediately, while those already in flight
+// are given until ctx is done to finish before the session is closed.
+// Stdio servers are terminated.
+func (h *McpHost) RemoveSession(ctx context.Context, name string) error {
+	h.mu.Lock()
+	server, ok := h.sessions[name]
+	delete(h.sessions, name)
+	h.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("invalid ClientSession name: %s", name)
+	}
+	return server.drain(ctx)
+}
+
+// Opens a session with a server according to cfg and, once it is ready, puts it in place of
+// the existing session with the server of the same name.
+// The old session is closed as by RemoveSession; if the new one cannot be opened, the old one is kept.
+// If cfg disables the server, the old session is simply removed.
+// If cfg differs from the server's configuration only in its roots, the session is kept
+// and the server is notified that its roots changed.
+// If a client is not specified, the server is given a client of its own.
+func (h *McpHost) ReplaceSession(ctx context.Context, cfg ServerConfig, client *mcp.Client) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	server, err := h.getServer(cfg.Name)
+	if err != nil {
+		return err
+	}
+	if !cfg.IsEnabled() {
+		return h.RemoveSession(ctx, cfg.Name)
+	}
+	if client == nil && !server.sharedClient && onlyRootsDiffer(server.configSnapshot(), cfg) {
+		return server.setConfigRoots(cfg.Roots)
+	}
+
+	serverClient, err := h.clientFor(&cfg, client)
+	if err != nil {
+		return err
+	}
+	// The server's logs are kept across restarts.
+	session, err := connectServer(ctx, serverClient, &cfg, server.logs)
+	if err != nil {
+		return err
+	}
+	replacement := newServerSession(cfg, serverClient, session, server.logs)
+	replacement.sharedClient = client != nil
+
+	h.mu.Lock()
+	old, ok := h.sessions[cfg.Name]
+	if !ok {
+		h.mu.Unlock()
+		replacement.close()
+		return fmt.Errorf("server '%s' was removed while it was being replaced", cfg.Name)
+	}
+	h.sessions[cfg.Name] = replacement
+	h.mu.Unlock()
+
+	go replacement.supervise()
+	return old.drain(ctx)
+}
+
+// Closes the sessions with every server, terminating stdio servers.
+func (h *McpHost) Close() error {
+	h.mu.Lock()
+	// The map is cleared in place, since copies of the host share it.
+	servers := maps.Clone(h.sessions)
+	clear(h.sessions)
+	h.mu.Unlock()
+
+	var errs []error
+	for name, server := range servers {
+		if err := server.close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing session '%s': %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lists the names of the servers with sessions, in lexical order.
 func (h *McpHost) ListServerNames() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
 	keys := make([]string, 0, len(h.sessions))
 	for k := range h.sessions {
 		keys = append(keys, k)
 	}
+	slices.Sort(keys)
 	return keys
 }
 
 // Gets a session for an MCP server with a particular name
 func (h *McpHost) GetSession(ctx context.Context, name string) (*mcp.ClientSession, error) {
-	session, ok := h.sessions[name]
-	if !ok {
-		return session, fmt.Errorf("invalid ClientSession name: %s", name)
+	server, err := h.getServer(name)
+	if err != nil {
+		return nil, err
 	}
-	return session, nil
+	return server.current()
 }
 
-func (h *McpHost) Tools(ctx context.Context) iter.Seq2[*agent.ServerTool, error] {
-	return func(yield func(*agent.ServerTool, error) bool) {
-		for _, serverName := range h.ListServerNames() {
-			session, err := h.GetSession(ctx, serverName)
-			if err != nil {
-				yield(nil, err)
-				return
-			}
-			for tool, err := range session.Tools(ctx, nil) {
-				if err != nil {
-					yield(nil, err)
-					return
-				}
-				yield(&agent.ServerTool{
-					ServerName: serverName,
-					Tool:       *tool,
-				}, nil)
-			}
-		}
+// Gets the state of the session with an MCP server
+func (h *McpHost) GetServerStatus(name string) (ServerStatus, error) {
+	server, err := h.getServer(name)
+	if err != nil {
+		return ServerStatus{}, err
 	}
+	return server.status(), nil
+}
+
+// Gets the configuration with which the session for an MCP server was opened
+func (h *McpHost) GetServerConfig(name string) (ServerConfig, error) {
+	server, err := h.getServer(name)
+	if err != nil {
+		return ServerConfig{}, err
+	}
+	return server.configSnapshot(), nil
+}
+
+func (h *McpHost) getServer(name string) (*serverSession, error) {
+	h.mu.RLock()
+	server, ok := h.sessions[name]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("invalid ClientSession name: %s", name)
+	}
+	return server, nil
 }
 
 // Lists all tools for a server that has an open session with this host
 func (h *McpHost) ListToolsOnServer(ctx context.Context, serverName string) ([]mcp.Tool, error) {
-	session, err := h.GetSession(ctx, serverName)
-
+	server, err := h.getServer(serverName)
 	if err != nil {
 		return nil, err
 	}
-	if session.InitializeResult().Capabilities.Tools == nil {
-		return []mcp.Tool{}, nil
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	serverTools, err := server.listTools(ctx, session)
+	if err != nil {
+		return nil, err
 	}
 
-	var tools []mcp.Tool
-
-	for tool, err := range session.Tools(ctx, nil) {
-		if err != nil {
-			return nil, fmt.Errorf("fetching tools for `%s`: %s", serverName, err)
+	tools := []mcp.Tool{}
+	for _, tool := range serverTools {
+		if server.config.Tools.Permits(tool.Name) {
+			tools = append(tools, *tool)
 		}
-		tools = append(tools, *tool)
 	}
 
 	return tools, nil
@@ -143,15 +324,71 @@ func (hmc HostMcpClient) CallTool(ctx context.Context, toolRequest *agent.Server
 		config = &api.ToolConfig{ToolId: toolRequestId, ToolPatch: api.ToolPatch{Input: nil}}
 	}
 
-	session, err := hmc.host.GetSession(ctx, toolRequest.ServerName)
+	server, err := hmc.host.getServer(toolRequest.ServerName)
 	if err != nil {
 		return nil, fmt.Errorf("could not connect to session '%s': %s", toolRequest.ServerName, err)
 	}
+	if !server.config.Tools.Permits(toolRequest.Name) {
+		return nil, fmt.Errorf("tool '%s' is not available on server '%s'", toolRequest.Name, toolRequest.ServerName)
+	}
 
-	res, err := session.CallTool(ctx, &patchToolRequest(toolRequest, config.ToolPatch).CallToolParams)
+	session, release, err := server.acquire()
 	if err != nil {
+		return nil, fmt.Errorf("could not connect to session '%s': %s", toolRequest.ServerName, err)
+	}
+	defer release()
+	if hmc.elicitor != nil {
+		defer server.addElicitor(hmc.elicitor)()
+	}
+
+	callCtx := ctx
+	timeout := hmc.callTimeout(server, toolRequestId)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	record := ToolCallRecord{
+		ServerName: toolRequest.ServerName,
+		ToolName:   toolRequest.Name,
+		Time:       time.Now(),
+		Outcome:    ToolCallCompleted,
+	}
+	defer func() {
+		record.Duration = time.Since(record.Time)
+		hmc.host.auditToolCall(record)
+	}()
+
+	params := patchToolRequest(toolRequest, config.ToolPatch).CallToolParams
+	if hmc.progress != nil {
+		defer hmc.host.trackProgress(&params, toolRequestId, hmc.progress)()
+	}
+
+	// If ctx is done before the call finishes, the server is sent notifications/cancelled.
+	res, err := session.CallTool(callCtx, &params)
+	if err != nil && ctx.Err() != nil {
+		record.Outcome = ToolCallCancelled
+		record.Error = context.Cause(ctx).Error()
+		return nil, fmt.Errorf("call to tool '%s' was cancelled: %w", toolRequest.Name, context.Cause(ctx))
+	}
+	if err != nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		record.Outcome = ToolCallTimedOut
+		record.Error = fmt.Sprintf("tool '%s' timed out after %s", toolRequest.Name, timeout)
+		// The agent is told, so that it may try something else.
+		toolUsePart := api.NewToolUsePartError(toolRequest.CallToolParams.Arguments, record.Error, toolRequestId)
+		return &toolUsePart, nil
+	}
+	if err != nil {
+		record.Outcome = ToolCallFailed
+		record.Error = err.Error()
+		server.reportFailure(err)
 		return nil, fmt.Errorf("error calling tool '%s': %s", toolRequest.Name, err)
 	}
+	if res.IsError {
+		record.Outcome = ToolCallFailed
+		record.Error = "the tool reported an error"
+	}
 
 	toolUsePart := api.NewToolUsePart(
 		toolRequest.CallToolParams.Arguments,
@@ -163,15 +400,31 @@ func (hmc HostMcpClient) CallTool(ctx context.Context, toolRequest *agent.Server
 	return &toolUsePart, nil
 }
 
+func (h *McpHost) auditToolCall(record ToolCallRecord) {
+	if h.opts.ToolCallAudit != nil {
+		h.opts.ToolCallAudit(record)
+		return
+	}
+	switch record.Outcome {
+	case ToolCallCancelled:
+		log.Printf("Cancelled call to tool '%s' on server '%s' after %s: %s", record.ToolName, record.ServerName, record.Duration, record.Error)
+	case ToolCallTimedOut, ToolCallFailed:
+		log.Printf("Call to tool '%s' on server '%s' %s after %s: %s", record.ToolName, record.ServerName, record.Outcome, record.Duration, record.Error)
+	}
+}
+
+// Lists the tools offered to the client.
+// The tools of servers that could not be listed are left out, as described by McpClient.
 func (hmc HostMcpClient) ListTools(ctx context.Context) ([]*agent.ServerTool, error) {
 
 	var serverTools []*agent.ServerTool
 
-	for tool, err := range hmc.host.Tools(ctx) {
-		if err != nil {
-			return serverTools, err
-		}
+	tools, listingErr := hmc.host.ListAllTools(ctx)
+	if _, ok := listingErr.(*agent.ToolListingError); listingErr != nil && !ok {
+		return nil, listingErr
+	}
 
+	for _, tool := range tools {
 		var config *api.ToolConfig
 		if cfg, ok := hmc.toolConfigs[*tool.ToolId()]; ok {
 			config = &cfg
@@ -184,7 +437,19 @@ func (hmc HostMcpClient) ListTools(ctx context.Context) ([]*agent.ServerTool, er
 		}
 
 	}
-	return serverTools, nil
+	return serverTools, listingErr
+}
+
+// Gets how long a call to the tool may take, or zero if there is no limit.
+// The client's tool configuration takes precedence over the server's timeouts.
+func (hmc HostMcpClient) callTimeout(server *serverSession, toolId api.ToolId) time.Duration {
+	if timeout, ok := hmc.toolTimeouts[toolId]; ok {
+		return timeout
+	}
+	if timeout, ok := server.config.ToolTimeouts[toolId.Name]; ok && timeout > 0 {
+		return time.Duration(timeout)
+	}
+	return time.Duration(server.config.CallTimeout)
 }
 
 func patchToolRequest(toolRequest *agent.ServerToolRequest, patch api.ToolPatch) *agent.ServerToolRequest {
@@ -242,73 +507,3 @@ func patchTool(tool *agent.ServerTool, patch api.ToolPatch) *agent.ServerTool {
 	}
 	return &patchedTool
 }
-
-func loadSessionsFromConfig(client *mcp.Client, ctx context.Context, r io.Reader) (map[string]*mcp.ClientSession, error) {
-	scanner := bufio.NewScanner(r)
-
-	sessions := make(map[string]*mcp.ClientSession)
-
-	for scanner.Scan() {
-		sessionWithName, err := sessionFromLine(client, ctx, scanner.Text())
-		if err != nil {
-			return sessions, err
-		}
-
-		if _, exists := sessions[sessionWithName.sessionName]; exists {
-			return sessions, fmt.Errorf("server name conflict: %s", sessionWithName.sessionName)
-		}
-		sessions[sessionWithName.sessionName] = sessionWithName.session
-	}
-
-	return sessions, nil
-}
-
-var stdioRegex = regexp.MustCompile(`^!\[([^\]]+)\](\[(\w[\w\d-_]*)\])?\s*(\S+)\s*(.*)$`)
-var httpRegex = regexp.MustCompile(`^>\[(\w[\w\d-_]*)\]\s*(http://.+)$`)
-
-func sessionFromLine(client *mcp.Client, ctx context.Context, line string) (clientSessionWithName, error) {
-	if matches := stdioRegex.FindStringSubmatch(line); len(matches) > 0 {
-		command := matches[len(matches)-2]
-		arguments := splitIntoWords(matches[len(matches)-1])
-		cmd := exec.Command(command, arguments...)
-		cmd.Dir = matches[1]
-		sessionName := command
-
-		if matches[3] != "" {
-			sessionName = matches[3]
-		}
-
-		transport := &mcp.CommandTransport{Command: cmd}
-		session, err := client.Connect(ctx, transport, nil)
-		if err != nil {
-			return clientSessionWithName{}, err
-		}
-		return clientSessionWithName{
-			session:     session,
-			sessionName: sessionName,
-		}, nil
-
-	} else if matches := httpRegex.FindStringSubmatch(line); len(matches) > 0 {
-		sessionName := matches[1]
-		url := matches[2]
-
-		session, err := client.Connect(ctx, &mcp.StreamableClientTransport{Endpoint: url}, nil)
-		if err != nil {
-			return clientSessionWithName{}, err
-		}
-
-		return clientSessionWithName{
-			session:     session,
-			sessionName: sessionName,
-		}, nil
-	}
-
-	return clientSessionWithName{}, fmt.Errorf("invalid line in config: %s", line)
-}
-
-var whiteSpaceRegex = regexp.MustCompile(`\s+`)
-
-func splitIntoWords(line string) []string {
-	words := whiteSpaceRegex.Split(strings.TrimSpace(line), -1)
-	return words
-}
@@ -2,11 +2,18 @@ package host
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestNewMcpHost(t *testing.T) {
@@ -25,6 +32,20 @@ func TestNewMcpHost(t *testing.T) {
 
 }
 
+func TestHostCopiesShareSessions(t *testing.T) {
+	ctx := context.Background()
+	host, _ := NewMcpHost(nil)
+	host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/greetings][greetings] go run greetings.go"), nil)
+	copied := host
+
+	if err := host.Close(); err != nil {
+		t.Fatalf("could not close the host: %s", err)
+	}
+	if names := copied.ListServerNames(); len(names) != 0 {
+		t.Errorf("expected a copy of the host to see its sessions closed but found %v", names)
+	}
+}
+
 func TestMultipleServers(t *testing.T) {
 	ctx := context.Background()
 
@@ -95,3 +116,262 @@ func TestHttpServer(t *testing.T) {
 		t.Fatalf("greeter-1's tool not added")
 	}
 }
+
+func TestReconnectAfterSessionFailure(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader(`
+servers:
+  - name: greeter
+    command: go
+    args: [run, greetings.go]
+    cwd: ../../test_servers/greetings
+    restart:
+      initialBackoff: 10ms
+`), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	session, _ := host.GetSession(ctx, "greeter")
+	session.Close()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		status, _ := host.GetServerStatus("greeter")
+		if status.State == StateReady && status.Restarts == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server was not reconnected; status is %+v", status)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	tools, err := host.ListToolsOnServer(ctx, "greeter")
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("expected the reconnected server to list its tool; found %v, %v", tools, err)
+	}
+}
+
+func TestFailedAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(newMathHandler())
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:    "math",
+		URL:     ts.URL,
+		Restart: RestartPolicy{MaxAttempts: 2, InitialBackoff: Duration(time.Millisecond)},
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	session, _ := host.GetSession(ctx, "math")
+	// The session's stream of server messages would otherwise hold up closing the server.
+	ts.CloseClientConnections()
+	ts.Close()
+	session.Close()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		status, _ := host.GetServerStatus("math")
+		if status.State == StateFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server was not marked as failed; status is %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := host.GetSession(ctx, "math"); err == nil {
+		t.Errorf("expected a failed server to have no usable session")
+	}
+}
+
+func TestRemoveSessionWaitsForToolCalls(t *testing.T) {
+	ctx := context.Background()
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	server := mcp.NewServer(&mcp.Implementation{Name: "slow", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "wait"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		close(entered)
+		<-unblock
+		return nil, struct{}{}, nil
+	})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	if err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{Name: "slow", URL: ts.URL}}}, nil); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	client, _ := host.GetClient(ctx, nil)
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(ctx, &agent.ServerToolRequest{ServerName: "slow", CallToolParams: mcp.CallToolParams{Name: "wait"}})
+		callErr <- err
+	}()
+	<-entered
+
+	removed := make(chan error, 1)
+	go func() {
+		removed <- host.RemoveSession(ctx, "slow")
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if names := host.ListServerNames(); len(names) != 0 {
+		t.Errorf("expected the server to be forgotten immediately but found %v", names)
+	}
+	select {
+	case <-removed:
+		t.Fatalf("session was closed while a tool call was in flight")
+	default:
+	}
+
+	close(unblock)
+	if err := <-callErr; err != nil {
+		t.Errorf("in-flight tool call failed: %s", err)
+	}
+	if err := <-removed; err != nil {
+		t.Errorf("could not remove session: %s", err)
+	}
+}
+
+func TestReplaceSession(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	cfg := ServerConfig{Name: "greeter", Command: "go", Args: []string{"run", "greetings.go"}, Cwd: "../../test_servers/greetings"}
+	if err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{cfg}}, nil); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			host.ListToolsOnServer(ctx, "greeter")
+		}()
+	}
+
+	cfg.Tools.Deny = []string{"greet"}
+	if err := host.ReplaceSession(ctx, cfg, nil); err != nil {
+		t.Fatalf("could not replace session: %s", err)
+	}
+	wg.Wait()
+
+	tools, err := host.ListToolsOnServer(ctx, "greeter")
+	if err != nil || len(tools) != 0 {
+		t.Errorf("expected the replacement's tool policy to hide all tools; found %v, %v", tools, err)
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "slow", Version: "v1.0.0"}, nil)
+	sleep := func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		select {
+		case <-ctx.Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+		return nil, struct{}{}, nil
+	}
+	mcp.AddTool(server, &mcp.Tool{Name: "sleep"}, sleep)
+	mcp.AddTool(server, &mcp.Tool{Name: "nap"}, sleep)
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:         "slow",
+		URL:          ts.URL,
+		CallTimeout:  Duration(50 * time.Millisecond),
+		ToolTimeouts: map[string]Duration{"nap": Duration(5 * time.Second)},
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	call := func(client agent.McpClient, name string) *api.ToolUsePart {
+		t.Helper()
+		res, err := client.CallTool(ctx, &agent.ServerToolRequest{ServerName: "slow", CallToolParams: mcp.CallToolParams{Name: name}})
+		if err != nil {
+			t.Fatalf("expected a timed-out call to be reported in its result but got %s", err)
+		}
+		return res
+	}
+
+	client, _ := host.GetClient(ctx, nil)
+	if res := call(client, "sleep"); !strings.Contains(res.Error, "timed out after 50ms") {
+		t.Errorf("expected the server's timeout to apply but found %+v", res)
+	}
+	if res := call(client, "nap"); res.Error != "" {
+		t.Errorf("expected the tool's timeout to take precedence but found %+v", res)
+	}
+
+	client, _ = host.GetClient(ctx, &ClientOptions{ToolConfigs: []*api.ToolConfig{
+		{ToolId: api.ToolId{ServerName: "slow", Name: "nap"}, Timeout: "10ms"},
+	}})
+	if res := call(client, "nap"); !strings.Contains(res.Error, "timed out after 10ms") {
+		t.Errorf("expected the client's timeout to take precedence but found %+v", res)
+	}
+
+	if _, err := host.GetClient(ctx, &ClientOptions{ToolConfigs: []*api.ToolConfig{
+		{ToolId: api.ToolId{ServerName: "slow", Name: "nap"}, Timeout: "soon"},
+	}}); err == nil {
+		t.Errorf("expected an invalid timeout to be rejected")
+	}
+}
+
+func TestCancelledToolCall(t *testing.T) {
+	entered := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := mcp.NewServer(&mcp.Implementation{Name: "slow", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "wait"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		close(entered)
+		<-ctx.Done()
+		close(cancelled)
+		return nil, struct{}{}, ctx.Err()
+	})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	records := make(chan ToolCallRecord, 1)
+	host, _ := NewMcpHost(&McpHostOptions{ToolCallAudit: func(record ToolCallRecord) { records <- record }})
+	defer host.Close()
+	if err := host.AddServers(context.Background(), &HostConfig{Servers: []ServerConfig{{Name: "slow", URL: ts.URL}}}, nil); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	client, _ := host.GetClient(context.Background(), nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-entered
+		cancel()
+	}()
+	if _, err := client.CallTool(ctx, &agent.ServerToolRequest{ServerName: "slow", CallToolParams: mcp.CallToolParams{Name: "wait"}}); err == nil {
+		t.Errorf("expected the cancelled call to fail")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected the server to be told that the call was cancelled")
+	}
+	if record := <-records; record.Outcome != ToolCallCancelled || record.ToolName != "wait" {
+		t.Errorf("expected the call to be recorded as cancelled but found %+v", record)
+	}
+}
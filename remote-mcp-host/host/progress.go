@@ -0,0 +1,64 @@
+package host
+
+import (
+	"context"
+	"crypto/rand"
+	"maps"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ProgressReporter receives the progress that servers report for the tool calls of a client.
+type ProgressReporter interface {
+	ReportProgress(progress api.ToolProgress)
+}
+
+// progressCall is a tool call in flight whose progress is reported.
+type progressCall struct {
+	reporter ProgressReporter
+	toolId   api.ToolId
+}
+
+// Attaches a new progress token to the tool call and routes the progress reported
+// under it to the reporter until the returned function is called.
+func (h *McpHost) trackProgress(params *mcp.CallToolParams, toolId api.ToolId, reporter ProgressReporter) (untrack func()) {
+	token := rand.Text()
+	// The caller's metadata is not modified.
+	params.Meta = maps.Clone(params.Meta)
+	if params.Meta == nil {
+		params.Meta = mcp.Meta{}
+	}
+	params.SetProgressToken(token)
+
+	h.progressMu.Lock()
+	h.progressCalls[token] = &progressCall{reporter: reporter, toolId: toolId}
+	h.progressMu.Unlock()
+	return func() {
+		h.progressMu.Lock()
+		delete(h.progressCalls, token)
+		h.progressMu.Unlock()
+	}
+}
+
+// Passes a progress notification from a server to the reporter of the tool call it belongs to.
+// Notifications for calls that already finished are dropped.
+func (h *McpHost) progressNotification(ctx context.Context, req *mcp.ProgressNotificationClientRequest) {
+	token, ok := req.Params.ProgressToken.(string)
+	if !ok {
+		return
+	}
+	h.progressMu.Lock()
+	call, ok := h.progressCalls[token]
+	h.progressMu.Unlock()
+	if !ok {
+		return
+	}
+	call.reporter.ReportProgress(api.ToolProgress{
+		CallId:   token,
+		ToolId:   call.toolId,
+		Progress: req.Params.Progress,
+		Total:    req.Params.Total,
+		Message:  req.Params.Message,
+	})
+}
@@ -0,0 +1,91 @@
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Lists the prompts offered by a server that has an open session with this host.
+func (h *McpHost) ListPromptsOnServer(ctx context.Context, serverName string) ([]mcp.Prompt, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	prompts := []mcp.Prompt{}
+	if session.InitializeResult().Capabilities.Prompts == nil {
+		return prompts, nil
+	}
+	for prompt, err := range session.Prompts(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("fetching prompts for '%s': %s", serverName, err)
+		}
+		prompts = append(prompts, *prompt)
+	}
+	return prompts, nil
+}
+
+// Renders a prompt from a server with the given arguments into messages.
+// Returns the messages along with the prompt's description.
+func (h *McpHost) GetPrompt(ctx context.Context, serverName string, promptName string, args map[string]string) ([]api.Message, string, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, "", err
+	}
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, "", err
+	}
+	defer release()
+
+	if session.InitializeResult().Capabilities.Prompts == nil {
+		return nil, "", fmt.Errorf("server '%s' does not offer prompts", serverName)
+	}
+	res, err := session.GetPrompt(ctx, &mcp.GetPromptParams{Name: promptName, Arguments: args})
+	if err != nil {
+		server.reportFailure(err)
+		return nil, "", fmt.Errorf("getting prompt '%s' from '%s': %s", promptName, serverName, err)
+	}
+
+	messages, err := promptMessagesToMessages(serverName, res.Messages)
+	if err != nil {
+		return nil, "", fmt.Errorf("rendering prompt '%s' from '%s': %s", promptName, serverName, err)
+	}
+	return messages, res.Description, nil
+}
+
+// Converts prompt messages, merging consecutive messages with the same role.
+func promptMessagesToMessages(serverName string, promptMessages []*mcp.PromptMessage) ([]api.Message, error) {
+	var messages []api.Message
+	for _, promptMessage := range promptMessages {
+		var part api.Part
+		switch content := promptMessage.Content.(type) {
+		case *mcp.TextContent:
+			part = api.NewTextPart(content.Text)
+		case *mcp.EmbeddedResource:
+			part = api.NewResourcePart(serverName, content.Resource)
+		default:
+			return nil, fmt.Errorf("unsupported content type %T", content)
+		}
+
+		role := "user"
+		if promptMessage.Role == "assistant" {
+			role = "model"
+		}
+		if len(messages) > 0 && messages[len(messages)-1].Role == role {
+			last := &messages[len(messages)-1]
+			last.Parts = append(last.Parts, api.ToUnion(part))
+			continue
+		}
+		messages = append(messages, api.Message{Role: role, Parts: []api.UnionPart{api.ToUnion(part)}})
+	}
+	return messages, nil
+}
@@ -0,0 +1,54 @@
+package host
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+func TestPrompts(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/prompts][prompts] go run prompts.go"), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	prompts, err := host.ListPromptsOnServer(ctx, "prompts")
+	if err != nil {
+		t.Fatalf("could not list prompts: %s", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "greet" {
+		t.Errorf("expected the greet prompt but found %v", prompts)
+	}
+
+	messages, description, err := host.GetPrompt(ctx, "prompts", "greet", map[string]string{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("could not get prompt: %s", err)
+	}
+	if description != "Greets Ada" {
+		t.Errorf("expected the description 'Greets Ada' but found '%s'", description)
+	}
+
+	roles := []string{}
+	for _, message := range messages {
+		roles = append(roles, message.Role)
+	}
+	if strings.Join(roles, ",") != "user,model,user" {
+		t.Fatalf("expected messages from user, model and user but found %v", roles)
+	}
+	if part, ok := messages[0].Parts[0].Part.(api.TextPart); !ok || part.Text != "Please greet Ada." {
+		t.Errorf("expected the first message to ask for a greeting but found %v", messages[0])
+	}
+	if part, ok := messages[2].Parts[0].Part.(api.ResourcePart); !ok || part.URI != "file:///motto.txt" || part.ServerName != "prompts" {
+		t.Errorf("expected the last message to embed the motto but found %v", messages[2])
+	}
+
+	if _, _, err := host.GetPrompt(ctx, "prompts", "missing", nil); err == nil {
+		t.Errorf("expected getting an unknown prompt to fail")
+	}
+}
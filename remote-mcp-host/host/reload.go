@@ -0,0 +1,118 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ReloadSummary reports what ApplyConfig changed, by server name.
+type ReloadSummary struct {
+	Added     []string
+	Removed   []string
+	Restarted []string
+	// Servers whose roots changed, which were notified without restarting.
+	Updated   []string
+	Unchanged []string
+	// Servers whose change could not be applied, with the reason.
+	Failed map[string]error
+}
+
+func (s ReloadSummary) String() string {
+	var parts []string
+	for _, group := range []struct {
+		label string
+		names []string
+	}{{"added", s.Added}, {"removed", s.Removed}, {"restarted", s.Restarted}, {"updated", s.Updated}} {
+		if len(group.names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", group.label, strings.Join(group.names, ", ")))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.Failed)) {
+		parts = append(parts, fmt.Sprintf("failed %s (%s)", name, s.Failed[name]))
+	}
+	parts = append(parts, fmt.Sprintf("%d unchanged", len(s.Unchanged)))
+	return strings.Join(parts, "; ")
+}
+
+// Brings the host in line with a host configuration, as when it is reloaded from a file.
+// Servers that are new are added, servers whose configuration changed are restarted and
+// servers that were in the previously applied configuration but are now missing or disabled are removed.
+// Servers whose roots alone changed are notified of the change instead of being restarted.
+// Sessions with unchanged servers, and servers added by other means that the configuration
+// does not name, are left untouched.
+//
+// Every change is attempted even if some fail; failures are listed in the summary and
+// joined into the returned error.
+// If a client is not specified, each server is given a client of its own.
+func (h *McpHost) ApplyConfig(ctx context.Context, cfg *HostConfig, client *mcp.Client) (ReloadSummary, error) {
+	summary := ReloadSummary{Failed: make(map[string]error)}
+	if err := cfg.Validate(); err != nil {
+		return summary, err
+	}
+
+	h.reloadMu.Lock()
+	defer h.reloadMu.Unlock()
+
+	applied := make(map[string]bool)
+	for _, serverCfg := range cfg.Servers {
+		if !serverCfg.IsEnabled() {
+			continue
+		}
+		applied[serverCfg.Name] = true
+
+		current, err := h.GetServerConfig(serverCfg.Name)
+		switch {
+		case err != nil:
+			err = h.AddServers(ctx, &HostConfig{Servers: []ServerConfig{serverCfg}}, client)
+			summary.record(&summary.Added, serverCfg.Name, err)
+		case client == nil && onlyRootsDiffer(current, serverCfg):
+			err = h.ReplaceSession(ctx, serverCfg, client)
+			summary.record(&summary.Updated, serverCfg.Name, err)
+		case !reflect.DeepEqual(current, serverCfg):
+			err = h.ReplaceSession(ctx, serverCfg, client)
+			summary.record(&summary.Restarted, serverCfg.Name, err)
+		default:
+			summary.Unchanged = append(summary.Unchanged, serverCfg.Name)
+		}
+	}
+
+	for name := range h.applied {
+		if applied[name] {
+			continue
+		}
+		if _, err := h.getServer(name); err != nil {
+			continue
+		}
+		err := h.RemoveSession(ctx, name)
+		summary.record(&summary.Removed, name, err)
+	}
+
+	slices.Sort(summary.Removed)
+
+	// Servers that failed to be added are still considered part of the configuration,
+	// so that they are retried by the next reload rather than forgotten.
+	// The map is replaced in place, since copies of the host share it.
+	clear(h.applied)
+	maps.Copy(h.applied, applied)
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(summary.Failed)) {
+		errs = append(errs, fmt.Errorf("server '%s': %w", name, summary.Failed[name]))
+	}
+	return summary, errors.Join(errs...)
+}
+
+func (s *ReloadSummary) record(names *[]string, name string, err error) {
+	if err != nil {
+		s.Failed[name] = err
+		return
+	}
+	*names = append(*names, name)
+}
@@ -0,0 +1,74 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestApplyConfig(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(newMathHandler())
+	defer ts.Close()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+
+	summary, err := host.ApplyConfig(ctx, &HostConfig{Servers: []ServerConfig{
+		{Name: "kept", URL: ts.URL},
+		{Name: "changed", URL: ts.URL},
+		{Name: "removed", URL: ts.URL},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not apply config: %s", err)
+	}
+	if len(summary.Added) != 3 {
+		t.Fatalf("expected 3 server(s) to be added but the summary was %s", summary)
+	}
+
+	// Servers added by other means are not managed by the configuration.
+	if err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{Name: "admin", URL: ts.URL}}}, nil); err != nil {
+		t.Fatalf("could not add server: %s", err)
+	}
+	kept, _ := host.GetSession(ctx, "kept")
+
+	summary, err = host.ApplyConfig(ctx, &HostConfig{Servers: []ServerConfig{
+		{Name: "kept", URL: ts.URL},
+		{Name: "changed", URL: ts.URL, Tools: ToolPolicy{Deny: []string{"add"}}},
+		{Name: "new", URL: ts.URL},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not apply config: %s", err)
+	}
+
+	if !slices.Equal(summary.Added, []string{"new"}) ||
+		!slices.Equal(summary.Restarted, []string{"changed"}) ||
+		!slices.Equal(summary.Removed, []string{"removed"}) ||
+		!slices.Equal(summary.Unchanged, []string{"kept"}) {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	if names := host.ListServerNames(); !slices.Equal(names, []string{"admin", "changed", "kept", "new"}) {
+		t.Errorf("unexpected servers after reload: %v", names)
+	}
+	if session, _ := host.GetSession(ctx, "kept"); session != kept {
+		t.Errorf("expected the session with an unchanged server to be left untouched")
+	}
+}
+
+func TestReloadSummaryListsFailuresInOrder(t *testing.T) {
+	summary := ReloadSummary{Failed: map[string]error{
+		"search":  errors.New("refused"),
+		"files":   errors.New("timed out"),
+		"weather": errors.New("not found"),
+	}}
+	expected := "failed files (timed out); failed search (refused); failed weather (not found); 0 unchanged"
+	for range 5 {
+		if got := summary.String(); got != expected {
+			t.Fatalf("expected '%s' but found '%s'", expected, got)
+		}
+	}
+}
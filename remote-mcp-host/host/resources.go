@@ -0,0 +1,82 @@
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Lists the resources offered by a server that has an open session with this host.
+func (h *McpHost) ListResourcesOnServer(ctx context.Context, serverName string) ([]mcp.Resource, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	resources := []mcp.Resource{}
+	if session.InitializeResult().Capabilities.Resources == nil {
+		return resources, nil
+	}
+	for resource, err := range session.Resources(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("fetching resources for '%s': %s", serverName, err)
+		}
+		resources = append(resources, *resource)
+	}
+	return resources, nil
+}
+
+// Lists the resource templates offered by a server that has an open session with this host.
+func (h *McpHost) ListResourceTemplatesOnServer(ctx context.Context, serverName string) ([]mcp.ResourceTemplate, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	templates := []mcp.ResourceTemplate{}
+	if session.InitializeResult().Capabilities.Resources == nil {
+		return templates, nil
+	}
+	for template, err := range session.ResourceTemplates(ctx, nil) {
+		if err != nil {
+			return nil, fmt.Errorf("fetching resource templates for '%s': %s", serverName, err)
+		}
+		templates = append(templates, *template)
+	}
+	return templates, nil
+}
+
+// Reads the contents of a resource from a server.
+// The URI may name a listed resource or match one of the server's resource templates.
+func (h *McpHost) ReadResource(ctx context.Context, serverName string, uri string) ([]*mcp.ResourceContents, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	session, release, err := server.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if session.InitializeResult().Capabilities.Resources == nil {
+		return nil, fmt.Errorf("server '%s' does not offer resources", serverName)
+	}
+	res, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		server.reportFailure(err)
+		return nil, fmt.Errorf("reading resource '%s' from '%s': %s", uri, serverName, err)
+	}
+	return res.Contents, nil
+}
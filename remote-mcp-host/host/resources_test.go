@@ -0,0 +1,49 @@
+package host
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestResources(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/resources][resources] go run resources.go\n![../../test_servers/greetings][greetings] go run greetings.go"), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	resources, err := host.ListResourcesOnServer(ctx, "resources")
+	if err != nil {
+		t.Fatalf("could not list resources: %s", err)
+	}
+	if len(resources) != 1 || resources[0].URI != "file:///motto.txt" {
+		t.Errorf("expected the motto resource but found %v", resources)
+	}
+
+	templates, err := host.ListResourceTemplatesOnServer(ctx, "resources")
+	if err != nil {
+		t.Fatalf("could not list resource templates: %s", err)
+	}
+	if len(templates) != 1 || templates[0].URITemplate != "greeting://{name}" {
+		t.Errorf("expected the greeting template but found %v", templates)
+	}
+
+	contents, err := host.ReadResource(ctx, "resources", "greeting://Ada")
+	if err != nil {
+		t.Fatalf("could not read resource: %s", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "Salutations, Ada." {
+		t.Errorf("expected a greeting for Ada but found %v", contents)
+	}
+
+	if resources, err := host.ListResourcesOnServer(ctx, "greetings"); err != nil || len(resources) != 0 {
+		t.Errorf("expected a server without resources to list none but found %v, %v", resources, err)
+	}
+	if _, err := host.ReadResource(ctx, "greetings", "file:///motto.txt"); err == nil {
+		t.Errorf("expected reading from a server without resources to fail")
+	}
+}
@@ -0,0 +1,141 @@
+package host
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Resolves the root to the file:// URI announced to servers.
+func (r *RootConfig) root() (*mcp.Root, error) {
+	if strings.HasPrefix(r.Path, "file://") {
+		return &mcp.Root{URI: r.Path, Name: r.Name}, nil
+	}
+	path, err := filepath.Abs(r.Path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving root '%s': %w", r.Path, err)
+	}
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return &mcp.Root{URI: uri.String(), Name: r.Name}, nil
+}
+
+func resolveRoots(configs []RootConfig) ([]*mcp.Root, error) {
+	var roots []*mcp.Root
+	for _, cfg := range configs {
+		root, err := cfg.root()
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// Gets the client with which sessions with the server are opened, announcing the server's roots.
+// If a client is not specified, one is made for the server alone.
+// Since the roots of a client are announced to all of its sessions, a server with roots
+// cannot be given a client that may be shared.
+func (h *McpHost) clientFor(cfg *ServerConfig, client *mcp.Client) (*mcp.Client, error) {
+	if client != nil {
+		if len(cfg.Roots) > 0 {
+			return nil, fmt.Errorf("server '%s' has roots, so it cannot be given a shared client", cfg.Name)
+		}
+		return client, nil
+	}
+	roots, err := resolveRoots(cfg.Roots)
+	if err != nil {
+		return nil, err
+	}
+	client = h.newClient()
+	client.AddRoots(roots...)
+	return client, nil
+}
+
+// Announces additional roots to a server until release is called.
+// Roots that the server already has are kept until they are released by every holder.
+// The roots are seen by every request to the server while they are held, not only by the holder's.
+// Servers that were given a shared client cannot be announced roots.
+func (h *McpHost) AddRoots(serverName string, roots ...*mcp.Root) (release func(), err error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return nil, err
+	}
+	if server.sharedClient {
+		return nil, fmt.Errorf("server '%s' was given a shared client, so roots cannot be announced to it", serverName)
+	}
+	for _, root := range roots {
+		if u, err := url.Parse(root.URI); err != nil || u.Scheme != "file" || !strings.HasPrefix(root.URI, "file://") {
+			return nil, fmt.Errorf("root '%s' is not a valid file:// URI", root.URI)
+		}
+	}
+	server.holdRoots(roots)
+	return func() { server.releaseRoots(roots) }, nil
+}
+
+func (s *serverSession) holdRoots(roots []*mcp.Root) {
+	s.rootsMu.Lock()
+	defer s.rootsMu.Unlock()
+	var added []*mcp.Root
+	for _, root := range roots {
+		s.rootRefs[root.URI]++
+		if s.rootRefs[root.URI] == 1 {
+			added = append(added, root)
+		}
+	}
+	// Notifies the server of the change.
+	s.client.AddRoots(added...)
+}
+
+func (s *serverSession) releaseRoots(roots []*mcp.Root) {
+	s.rootsMu.Lock()
+	defer s.rootsMu.Unlock()
+	var removed []string
+	for _, root := range roots {
+		if s.rootRefs[root.URI] == 0 {
+			continue
+		}
+		s.rootRefs[root.URI]--
+		if s.rootRefs[root.URI] == 0 {
+			delete(s.rootRefs, root.URI)
+			removed = append(removed, root.URI)
+		}
+	}
+	if len(removed) > 0 {
+		s.client.RemoveRoots(removed...)
+	}
+}
+
+// Replaces the roots from the server's configuration, notifying the server of the change
+// without reopening the session.
+func (s *serverSession) setConfigRoots(configs []RootConfig) error {
+	roots, err := resolveRoots(configs)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	oldConfigs := s.config.Roots
+	s.config.Roots = configs
+	s.mu.Unlock()
+
+	oldRoots, err := resolveRoots(oldConfigs)
+	if err != nil {
+		return err
+	}
+	s.holdRoots(roots)
+	s.releaseRoots(oldRoots)
+	return nil
+}
+
+// Reports whether two configurations of a server differ in their roots alone.
+func onlyRootsDiffer(a ServerConfig, b ServerConfig) bool {
+	if reflect.DeepEqual(a.Roots, b.Roots) {
+		return false
+	}
+	a.Roots, b.Roots = nil, nil
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,150 @@
+package host
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func rootsServerConfig(roots ...RootConfig) ServerConfig {
+	return ServerConfig{
+		Name:    "roots",
+		Command: "go",
+		Args:    []string{"run", "roots.go"},
+		Cwd:     "../../test_servers/roots",
+		Roots:   roots,
+	}
+}
+
+// Asks the roots server which roots it sees and how many changes it was notified of.
+func listRoots(t *testing.T, ctx context.Context, host *McpHost) ([]string, int) {
+	t.Helper()
+	client, _ := host.GetClient(ctx, nil)
+	res, err := client.CallTool(ctx, &agent.ServerToolRequest{
+		ServerName:     "roots",
+		CallToolParams: mcp.CallToolParams{Name: "listroots", Arguments: map[string]any{}},
+	})
+	if err != nil || res.Output.IsError {
+		t.Fatalf("could not list roots: %v %v", err, res)
+	}
+	output := res.Output.StructuredContent.(map[string]any)
+	var roots []string
+	for _, root := range output["roots"].([]any) {
+		roots = append(roots, root.(string))
+	}
+	slices.Sort(roots)
+	return roots, int(output["changes"].(float64))
+}
+
+// Waits for the roots server to have been notified of the given number of changes.
+func waitForRootChanges(t *testing.T, ctx context.Context, host *McpHost, changes int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		roots, seen := listRoots(t, ctx, host)
+		if seen >= changes || time.Now().After(deadline) {
+			if seen != changes {
+				t.Fatalf("expected %d root change notification(s) but found %d", changes, seen)
+			}
+			return roots
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestRoots(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{
+		rootsServerConfig(RootConfig{Path: "/srv/data"}, RootConfig{Path: "file:///srv/logs", Name: "logs"}),
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	roots, changes := listRoots(t, ctx, &host)
+	if !slices.Equal(roots, []string{"file:///srv/data", "file:///srv/logs"}) || changes != 0 {
+		t.Fatalf("expected the configured roots without changes but found %v after %d change(s)", roots, changes)
+	}
+
+	release, err := host.AddRoots("roots", &mcp.Root{URI: "file:///srv/scratch"})
+	if err != nil {
+		t.Fatalf("could not add roots: %s", err)
+	}
+	roots = waitForRootChanges(t, ctx, &host, 1)
+	if !slices.Contains(roots, "file:///srv/scratch") {
+		t.Errorf("expected the added root to be announced but found %v", roots)
+	}
+
+	release()
+	roots = waitForRootChanges(t, ctx, &host, 2)
+	if slices.Contains(roots, "file:///srv/scratch") || len(roots) != 2 {
+		t.Errorf("expected the added root to be withdrawn but found %v", roots)
+	}
+
+	if _, err := host.AddRoots("roots", &mcp.Root{URI: "https://example.com"}); err == nil {
+		t.Errorf("expected a root that is not a file:// URI to be rejected")
+	}
+	if _, err := host.AddRoots("roots", &mcp.Root{URI: "file:///srv/%zz"}); err == nil {
+		t.Errorf("expected a root that does not parse to be rejected")
+	}
+}
+
+func TestRootsNeedOwnClient(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	shared := host.newClient()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{rootsServerConfig(RootConfig{Path: "/srv/data"})}}, shared)
+	if err == nil {
+		t.Fatalf("expected a server with roots to be refused a shared client")
+	}
+
+	if err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{rootsServerConfig()}}, shared); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	if _, err := host.AddRoots("roots", &mcp.Root{URI: "file:///srv/scratch"}); err == nil {
+		t.Errorf("expected roots not to be announced through a shared client")
+	}
+}
+
+func TestApplyConfigUpdatesRoots(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	if _, err := host.ApplyConfig(ctx, &HostConfig{Servers: []ServerConfig{
+		rootsServerConfig(RootConfig{Path: "/srv/data"}),
+	}}, nil); err != nil {
+		t.Fatalf("could not apply config: %s", err)
+	}
+	session, _ := host.GetSession(ctx, "roots")
+
+	summary, err := host.ApplyConfig(ctx, &HostConfig{Servers: []ServerConfig{
+		rootsServerConfig(RootConfig{Path: "/srv/other"}),
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not apply config: %s", err)
+	}
+	if !slices.Equal(summary.Updated, []string{"roots"}) || len(summary.Restarted) != 0 {
+		t.Errorf("expected the server to be updated without restarting but found %s", summary)
+	}
+	if updated, _ := host.GetSession(ctx, "roots"); updated != session {
+		t.Errorf("expected the session to be kept")
+	}
+
+	roots := waitForRootChanges(t, ctx, &host, 2)
+	if !slices.Equal(roots, []string{"file:///srv/other"}) {
+		t.Errorf("expected only the new root but found %v", roots)
+	}
+	if cfg, _ := host.GetServerConfig("roots"); len(cfg.Roots) != 1 || cfg.Roots[0].Path != "/srv/other" {
+		t.Errorf("expected the configuration to hold the new roots but found %v", cfg.Roots)
+	}
+}
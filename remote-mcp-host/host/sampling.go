@@ -0,0 +1,141 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Answers a sampling request from a server with the host's sampler,
+// if the server's configuration enables sampling.
+func (h *McpHost) createMessage(ctx context.Context, req *mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	record := SamplingRecord{
+		Time:    time.Now(),
+		Request: req.Params,
+	}
+
+	res, err := h.sample(ctx, req, &record)
+
+	record.Duration = time.Since(record.Time)
+	record.Result = res
+	if err != nil {
+		record.Error = err.Error()
+	}
+	h.auditSampling(record)
+
+	return res, err
+}
+
+func (h *McpHost) sample(ctx context.Context, req *mcp.CreateMessageRequest, record *SamplingRecord) (*mcp.CreateMessageResult, error) {
+	server, ok := h.serverOfSession(req.Session)
+	if !ok {
+		return nil, fmt.Errorf("sampling is not available to this session")
+	}
+	record.ServerName = server.config.Name
+
+	policy := server.config.Sampling
+	if !policy.Enabled {
+		return nil, fmt.Errorf("sampling is not enabled for server '%s'", server.config.Name)
+	}
+
+	messages, err := samplingMessagesToMessages(req.Params.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := int(req.Params.MaxTokens)
+	if policy.MaxTokens > 0 && (maxTokens <= 0 || maxTokens > policy.MaxTokens) {
+		maxTokens = policy.MaxTokens
+	}
+
+	opts := &agent.GenerateOptions{
+		SystemPrompt:    req.Params.SystemPrompt,
+		MaxOutputTokens: maxTokens,
+		StopSequences:   req.Params.StopSequences,
+	}
+	// A temperature of zero cannot be told apart from none.
+	if req.Params.Temperature != 0 {
+		opts.Temperature = &req.Params.Temperature
+	}
+	res, err := h.opts.Sampler.Act(ctx, noToolsClient{}, messages, opts)
+	if err != nil {
+		return nil, fmt.Errorf("sampling failed: %s", err)
+	}
+
+	var text strings.Builder
+	for _, part := range res.Message.Parts {
+		if tp, ok := part.Part.(api.TextPart); ok {
+			text.WriteString(tp.Text)
+		}
+	}
+	return &mcp.CreateMessageResult{
+		Content:    &mcp.TextContent{Text: text.String()},
+		Model:      res.Model,
+		Role:       "assistant",
+		StopReason: "endTurn",
+	}, nil
+}
+
+// Finds the server with which the session was opened.
+func (h *McpHost) serverOfSession(session *mcp.ClientSession) (*serverSession, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, server := range h.sessions {
+		server.mu.Lock()
+		match := server.session == session
+		server.mu.Unlock()
+		if match {
+			return server, true
+		}
+	}
+	return nil, false
+}
+
+func (h *McpHost) auditSampling(record SamplingRecord) {
+	if h.opts.SamplingAudit != nil {
+		h.opts.SamplingAudit(record)
+		return
+	}
+	if record.Error != "" {
+		log.Printf("Refused sampling request from server '%s' after %s: %s", record.ServerName, record.Duration, record.Error)
+		return
+	}
+	log.Printf("Answered sampling request from server '%s' with model '%s' in %s", record.ServerName, record.Result.Model, record.Duration)
+}
+
+func samplingMessagesToMessages(samplingMessages []*mcp.SamplingMessage) ([]api.Message, error) {
+	var messages []api.Message
+	for _, samplingMessage := range samplingMessages {
+		text, ok := samplingMessage.Content.(*mcp.TextContent)
+		if !ok {
+			return nil, fmt.Errorf("only text content is supported for sampling")
+		}
+
+		role := "user"
+		if samplingMessage.Role == "assistant" {
+			role = "model"
+		}
+		messages = append(messages, api.Message{
+			Role:  role,
+			Parts: []api.UnionPart{api.ToUnion(api.NewTextPart(text.Text))},
+		})
+	}
+	return messages, nil
+}
+
+// noToolsClient offers no tools, so that sampling cannot be used to reach other servers.
+type noToolsClient struct{}
+
+func (noToolsClient) ListTools(ctx context.Context) ([]*agent.ServerTool, error) {
+	return []*agent.ServerTool{}, nil
+}
+
+func (noToolsClient) CallTool(ctx context.Context, toolRequest *agent.ServerToolRequest) (*api.ToolUsePart, error) {
+	return nil, fmt.Errorf("no tools are available while sampling")
+}
@@ -0,0 +1,111 @@
+package host
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/internal/testutil"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// recordingSampler echoes the prompt and remembers the options it was given.
+type recordingSampler struct {
+	testutil.EchoAgent
+	mu   sync.Mutex
+	opts []agent.GenerateOptions
+}
+
+func (s *recordingSampler) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+	s.mu.Lock()
+	s.opts = append(s.opts, *opts)
+	s.mu.Unlock()
+	return s.EchoAgent.Act(ctx, client, messages, opts)
+}
+
+func callInputPing(ctx context.Context, host *McpHost) (*api.ToolUsePart, error) {
+	client, _ := host.GetClient(ctx, nil)
+	return client.CallTool(ctx, &agent.ServerToolRequest{
+		ServerName:     "sampling",
+		CallToolParams: mcp.CallToolParams{Name: "inputping", Arguments: map[string]any{"name": "x"}},
+	})
+}
+
+func TestSampling(t *testing.T) {
+	ctx := context.Background()
+
+	sampler := &recordingSampler{}
+	var records []SamplingRecord
+	host, _ := NewMcpHost(&McpHostOptions{
+		Sampler:       sampler,
+		SamplingAudit: func(record SamplingRecord) { records = append(records, record) },
+	})
+	defer host.Close()
+
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader(`
+servers:
+  - name: sampling
+    command: go
+    args: [run, sampling.go]
+    cwd: ../../test_servers/sampling
+    sampling:
+      enabled: true
+      maxTokens: 2
+`), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	res, err := callInputPing(ctx, &host)
+	if err != nil {
+		t.Fatalf("could not call tool: %s", err)
+	}
+	if res.Output.IsError {
+		t.Fatalf("expected the tool to succeed but it failed with %v", res.Output.Content)
+	}
+	output, _ := res.Output.StructuredContent.(map[string]any)
+	if greeting, _ := output["greeting"].(string); greeting != `xPingRespond only with the word "Pang".Pong` {
+		t.Errorf("expected the sampled text to be pinged and ponged but found '%s'", greeting)
+	}
+
+	if len(sampler.opts) != 1 || sampler.opts[0].MaxOutputTokens != 2 {
+		t.Errorf("expected the server's request for 4 tokens to be capped at 2 but found %+v", sampler.opts)
+	}
+	if len(records) != 1 || records[0].ServerName != "sampling" || records[0].Error != "" || records[0].Result.Model != "echo" {
+		t.Errorf("expected one audited exchange with server 'sampling' but found %+v", records)
+	}
+}
+
+func TestSamplingDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	sampler := &recordingSampler{}
+	var records []SamplingRecord
+	host, _ := NewMcpHost(&McpHostOptions{
+		Sampler:       sampler,
+		SamplingAudit: func(record SamplingRecord) { records = append(records, record) },
+	})
+	defer host.Close()
+
+	err := host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/sampling][sampling] go run sampling.go"), nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	res, err := callInputPing(ctx, &host)
+	if err != nil {
+		t.Fatalf("could not call tool: %s", err)
+	}
+	if !res.Output.IsError {
+		t.Errorf("expected the tool to fail without sampling")
+	}
+	if len(sampler.opts) != 0 {
+		t.Errorf("expected the sampler not to be used")
+	}
+	if len(records) != 1 || records[0].Error == "" {
+		t.Errorf("expected one audited refusal but found %+v", records)
+	}
+}
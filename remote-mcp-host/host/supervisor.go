@@ -0,0 +1,327 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	defaultInitialBackoff      = 500 * time.Millisecond
+	defaultMaxBackoff          = 30 * time.Second
+	defaultHealthCheckInterval = 30 * time.Second
+	// The number of consecutive failed health checks after which a session is reopened.
+	unhealthyThreshold = 3
+)
+
+// Reported to requests made to a server while it is being removed.
+var errDraining = errors.New("being removed")
+
+// serverSession is a supervised session with an MCP server.
+// The session is reopened whenever the connection is lost or the server stops answering pings.
+type serverSession struct {
+	config ServerConfig
+	client *mcp.Client
+	logs   *serverLogs
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// Receives failures observed by callers, such as a closed connection during a tool call.
+	failures chan error
+	// Tracks the requests in flight, so that closing can wait for them.
+	inFlight sync.WaitGroup
+
+	// Whether the client was given by the caller, who may share it with other servers,
+	// in which case roots announced through it would be seen by them too.
+	sharedClient bool
+
+	// Counts the holders of each root announced to the server, by URI.
+	rootsMu  sync.Mutex
+	rootRefs map[string]int
+
+	toolCache toolCache
+
+	mu       sync.Mutex
+	session  *mcp.ClientSession
+	state    ServerState
+	lastErr  error
+	restarts int
+	since    time.Time
+	draining bool
+	// The elicitors of the tool calls in flight, one for each call.
+	elicitors []*elicitorHandle
+}
+
+// The client must already announce the roots in cfg.
+func newServerSession(cfg ServerConfig, client *mcp.Client, session *mcp.ClientSession, logs *serverLogs) *serverSession {
+	ctx, cancel := context.WithCancel(context.Background())
+	rootRefs := make(map[string]int)
+	if roots, err := resolveRoots(cfg.Roots); err == nil {
+		for _, root := range roots {
+			rootRefs[root.URI]++
+		}
+	}
+	return &serverSession{
+		config:   cfg,
+		client:   client,
+		logs:     logs,
+		ctx:      ctx,
+		cancel:   cancel,
+		failures: make(chan error, 1),
+		rootRefs: rootRefs,
+		session:  session,
+		state:    StateReady,
+		since:    time.Now(),
+	}
+}
+
+// Gets a copy of the server's configuration, whose roots may change while the session is open.
+func (s *serverSession) configSnapshot() ServerConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// Gets the session if it is usable, i.e. ready or degraded.
+func (s *serverSession) current() (*mcp.ClientSession, error) {
+	session, release, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return session, nil
+}
+
+// Gets the session for the duration of a request.
+// The session is not closed by drain until release is called.
+func (s *serverSession) acquire() (session *mcp.ClientSession, release func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.draining {
+		return nil, nil, fmt.Errorf("server '%s' is %w", s.config.Name, errDraining)
+	}
+	if s.state != StateReady && s.state != StateDegraded {
+		if s.lastErr != nil {
+			return nil, nil, fmt.Errorf("server '%s' is %s: %s", s.config.Name, s.state, s.lastErr)
+		}
+		return nil, nil, fmt.Errorf("server '%s' is %s", s.config.Name, s.state)
+	}
+	s.inFlight.Add(1)
+	return s.session, s.inFlight.Done, nil
+}
+
+func (s *serverSession) status() ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := ServerStatus{
+		Name:     s.config.Name,
+		State:    s.state,
+		Restarts: s.restarts,
+		Since:    s.since,
+	}
+	if s.lastErr != nil {
+		status.Error = s.lastErr.Error()
+	}
+	return status
+}
+
+func (s *serverSession) setState(state ServerState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == StateStopped {
+		return
+	}
+	if s.state != state {
+		s.since = time.Now()
+	}
+	s.state = state
+	s.lastErr = err
+}
+
+// Reports a failure observed while using the session so that it is reopened.
+// Errors that do not indicate a broken connection are ignored.
+func (s *serverSession) reportFailure(err error) {
+	if !errors.Is(err, mcp.ErrConnectionClosed) {
+		return
+	}
+	select {
+	case s.failures <- err:
+	default:
+	}
+}
+
+// Refuses new requests, waits for those in flight to finish or for ctx to be done,
+// and then closes the session.
+func (s *serverSession) drain(ctx context.Context) error {
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("Closing session with server '%s' before its requests finished: %s", s.config.Name, ctx.Err())
+	}
+	return s.close()
+}
+
+// Stops supervision and closes the session.
+func (s *serverSession) close() error {
+	s.cancel()
+	s.mu.Lock()
+	session := s.session
+	s.session = nil
+	s.state = StateStopped
+	s.since = time.Now()
+	s.mu.Unlock()
+
+	if session == nil {
+		return nil
+	}
+	return session.Close()
+}
+
+// Watches the session until it is closed, reopening it whenever it fails.
+func (s *serverSession) supervise() {
+	for {
+		s.mu.Lock()
+		session := s.session
+		s.mu.Unlock()
+		if session == nil {
+			return
+		}
+
+		err := s.waitForFailure(session)
+		if s.ctx.Err() != nil {
+			return
+		}
+		log.Printf("Session with server '%s' failed: %s", s.config.Name, err)
+		session.Close()
+
+		if s.config.Restart.Disabled {
+			s.setState(StateFailed, err)
+			return
+		}
+		if !s.reconnect(err) {
+			return
+		}
+	}
+}
+
+// Blocks until the session fails or supervision stops, returning the cause of the failure.
+func (s *serverSession) waitForFailure(session *mcp.ClientSession) error {
+	closed := make(chan error, 1)
+	go func() {
+		closed <- session.Wait()
+	}()
+
+	interval := s.config.Restart.healthCheckInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failedChecks := 0
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case err := <-closed:
+			if err == nil {
+				err = mcp.ErrConnectionClosed
+			}
+			return err
+		case err := <-s.failures:
+			return err
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(s.ctx, interval)
+			err := session.Ping(ctx, nil)
+			cancel()
+			if err == nil {
+				failedChecks = 0
+				s.setState(StateReady, nil)
+				continue
+			}
+			failedChecks++
+			if failedChecks >= unhealthyThreshold {
+				return fmt.Errorf("health check failed: %w", err)
+			}
+			s.setState(StateDegraded, fmt.Errorf("health check failed: %w", err))
+		}
+	}
+}
+
+// Reopens the session, backing off exponentially between attempts.
+// Returns false if supervision stopped or the attempts were exhausted.
+func (s *serverSession) reconnect(cause error) bool {
+	policy := s.config.Restart
+	backoff := policy.initialBackoff()
+	lastErr := cause
+
+	for attempt := 1; policy.MaxAttempts <= 0 || attempt <= policy.MaxAttempts; attempt++ {
+		s.setState(StateConnecting, lastErr)
+
+		select {
+		case <-s.ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+
+		session, err := connectServer(s.ctx, s.client, &s.config, s.logs)
+		if err == nil {
+			s.mu.Lock()
+			if s.ctx.Err() != nil {
+				s.mu.Unlock()
+				session.Close()
+				return false
+			}
+			s.session = session
+			s.state = StateReady
+			s.lastErr = nil
+			s.restarts++
+			s.since = time.Now()
+			s.mu.Unlock()
+			// The reopened server may offer different tools.
+			s.invalidateTools()
+			log.Printf("Reopened session with server '%s' after %d attempt(s)", s.config.Name, attempt)
+			return true
+		}
+
+		lastErr = err
+		log.Printf("Could not reopen session with server '%s' (attempt %d): %s", s.config.Name, attempt, err)
+		backoff = min(2*backoff, policy.maxBackoff())
+	}
+
+	s.setState(StateFailed, lastErr)
+	return false
+}
+
+func (p *RestartPolicy) initialBackoff() time.Duration {
+	if p.InitialBackoff > 0 {
+		return time.Duration(p.InitialBackoff)
+	}
+	return defaultInitialBackoff
+}
+
+func (p *RestartPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff > 0 {
+		return max(time.Duration(p.MaxBackoff), p.initialBackoff())
+	}
+	return max(defaultMaxBackoff, p.initialBackoff())
+}
+
+func (p *RestartPolicy) healthCheckInterval() time.Duration {
+	if p.HealthCheckInterval > 0 {
+		return time.Duration(p.HealthCheckInterval)
+	}
+	return defaultHealthCheckInterval
+}
@@ -0,0 +1,195 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const defaultListToolsTimeout = 10 * time.Second
+
+// ToolCacheStats counts how the tool listings of a server were answered.
+type ToolCacheStats struct {
+	// Listings answered from the cache.
+	Hits int64 `json:"hits"`
+	// Listings that had to ask the server.
+	Misses int64 `json:"misses"`
+	// Times the cache was discarded, because the server's tools changed, the session was reopened
+	// or a refresh was requested.
+	Invalidations int64 `json:"invalidations"`
+}
+
+// toolCache holds the tools listed by a server's current session.
+type toolCache struct {
+	mu sync.Mutex
+	// nil until the tools are listed again.
+	tools []*mcp.Tool
+	// Incremented by every invalidation, so that a listing begun before one is not cached.
+	epoch int
+	stats ToolCacheStats
+}
+
+// Lists the tools of the server, asking the session only if they are not cached.
+// The tools of a server with a shared client are not cached, since the host does not
+// handle the client's notifications and so cannot learn that they changed.
+// The tools must not be modified.
+func (s *serverSession) listTools(ctx context.Context, session *mcp.ClientSession) ([]*mcp.Tool, error) {
+	c := &s.toolCache
+	c.mu.Lock()
+	if c.tools != nil {
+		c.stats.Hits++
+		tools := c.tools
+		c.mu.Unlock()
+		return tools, nil
+	}
+	c.stats.Misses++
+	epoch := c.epoch
+	c.mu.Unlock()
+
+	tools := []*mcp.Tool{}
+	if session.InitializeResult().Capabilities.Tools != nil {
+		for tool, err := range session.Tools(ctx, nil) {
+			if err != nil {
+				s.reportFailure(err)
+				return nil, fmt.Errorf("fetching tools for '%s': %s", s.config.Name, err)
+			}
+			tools = append(tools, tool)
+		}
+	}
+
+	c.mu.Lock()
+	if c.epoch == epoch && !s.sharedClient {
+		c.tools = tools
+	}
+	c.mu.Unlock()
+	return tools, nil
+}
+
+func (s *serverSession) invalidateTools() {
+	c := &s.toolCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tools = nil
+	c.epoch++
+	c.stats.Invalidations++
+}
+
+func (s *serverSession) toolCacheStats() ToolCacheStats {
+	s.toolCache.mu.Lock()
+	defer s.toolCache.mu.Unlock()
+	return s.toolCache.stats
+}
+
+// Lists the tools of every server, asking the servers concurrently.
+// A server that fails or takes longer than its ListToolsTimeout is left out, and its error is
+// reported in a *agent.ToolListingError returned along with the tools of the other servers,
+// as is a server that is not ready, such as one being reconnected or one that failed.
+// Servers that are being removed are left out silently.
+func (h *McpHost) ListAllTools(ctx context.Context) ([]*agent.ServerTool, error) {
+	names := h.ListServerNames()
+	serverTools := make([][]*agent.ServerTool, len(names))
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Go(func() {
+			serverTools[i], errs[i] = h.listServerTools(ctx, name)
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var tools []*agent.ServerTool
+	listingErr := &agent.ToolListingError{Errors: make(map[string]error)}
+	for i, name := range names {
+		if errs[i] != nil {
+			listingErr.Errors[name] = errs[i]
+			continue
+		}
+		tools = append(tools, serverTools[i]...)
+	}
+	if len(listingErr.Errors) > 0 {
+		return tools, listingErr
+	}
+	return tools, nil
+}
+
+func (h *McpHost) listServerTools(ctx context.Context, serverName string) ([]*agent.ServerTool, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		// The server was removed after the names were listed.
+		return nil, nil
+	}
+	session, release, err := server.acquire()
+	if errors.Is(err, errDraining) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, server.config.listToolsTimeout())
+	defer cancel()
+	tools, err := server.listTools(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	var serverTools []*agent.ServerTool
+	for _, tool := range tools {
+		if server.config.Tools.Permits(tool.Name) {
+			serverTools = append(serverTools, &agent.ServerTool{ServerName: serverName, Tool: *tool})
+		}
+	}
+	return serverTools, nil
+}
+
+func (c *ServerConfig) listToolsTimeout() time.Duration {
+	if c.ListToolsTimeout > 0 {
+		return time.Duration(c.ListToolsTimeout)
+	}
+	return defaultListToolsTimeout
+}
+
+// Discards the cached tools of the server that sent a tools/list_changed notification.
+func (h *McpHost) toolListChanged(ctx context.Context, req *mcp.ToolListChangedRequest) {
+	server, ok := h.serverOfSession(req.Session)
+	if !ok {
+		return
+	}
+	server.invalidateTools()
+}
+
+// Discards the cached tools of a server and lists them again.
+func (h *McpHost) RefreshTools(ctx context.Context, serverName string) error {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return err
+	}
+	server.invalidateTools()
+
+	session, release, err := server.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+	_, err = server.listTools(ctx, session)
+	return err
+}
+
+// Gets the statistics of the tool cache of a server.
+func (h *McpHost) GetToolCacheStats(serverName string) (ToolCacheStats, error) {
+	server, err := h.getServer(serverName)
+	if err != nil {
+		return ToolCacheStats{}, err
+	}
+	return server.toolCacheStats(), nil
+}
@@ -0,0 +1,187 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func toolNames(t *testing.T, ctx context.Context, client agent.McpClient) []string {
+	t.Helper()
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("could not list tools: %s", err)
+	}
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestToolCache(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:    "tools",
+		Command: "go",
+		Args:    []string{"run", "tools.go"},
+		Cwd:     "../../test_servers/tools",
+	}}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	client, _ := host.GetClient(ctx, nil)
+
+	for range 3 {
+		if names := toolNames(t, ctx, client); !slices.Equal(names, []string{"learn"}) {
+			t.Fatalf("expected only the learn tool but found %v", names)
+		}
+	}
+	if stats, _ := host.GetToolCacheStats("tools"); stats.Misses != 1 || stats.Hits != 2 {
+		t.Errorf("expected the tools to be listed once and then cached but found %+v", stats)
+	}
+
+	res, err := client.CallTool(ctx, &agent.ServerToolRequest{
+		ServerName:     "tools",
+		CallToolParams: mcp.CallToolParams{Name: "learn", Arguments: map[string]any{"name": "juggle"}},
+	})
+	if err != nil || res.Output.IsError {
+		t.Fatalf("could not learn a tool: %v %v", err, res)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stats, _ := host.GetToolCacheStats("tools")
+		if stats.Invalidations > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the tools/list_changed notification to invalidate the cache")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if names := toolNames(t, ctx, client); !slices.Equal(names, []string{"juggle", "learn"}) {
+		t.Errorf("expected the learned tool to be listed but found %v", names)
+	}
+
+	if err := host.RefreshTools(ctx, "tools"); err != nil {
+		t.Fatalf("could not refresh tools: %s", err)
+	}
+	if stats, _ := host.GetToolCacheStats("tools"); stats.Misses != 3 || stats.Invalidations != 2 {
+		t.Errorf("expected the refresh to list the tools again but found %+v", stats)
+	}
+	if err := host.RefreshTools(ctx, "missing"); err == nil {
+		t.Errorf("expected refreshing an unknown server to fail")
+	}
+}
+
+func TestListAllToolsLeavesOutSlowServers(t *testing.T) {
+	ctx := context.Background()
+
+	server := mcp.NewServer(&mcp.Implementation{Name: "hanging", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "never"}, func(ctx context.Context, req *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, struct{}, error) {
+		return nil, struct{}{}, nil
+	})
+	server.AddReceivingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if method == "tools/list" {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return next(ctx, method, req)
+		}
+	})
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{
+		{Name: "hanging", URL: ts.URL, ListToolsTimeout: Duration(100 * time.Millisecond)},
+		{Name: "greetings", Command: "go", Args: []string{"run", "greetings.go"}, Cwd: "../../test_servers/greetings"},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	start := time.Now()
+	tools, err := host.ListAllTools(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the hanging server to be given up on but listing took %s", elapsed)
+	}
+
+	var listingErr *agent.ToolListingError
+	if !errors.As(err, &listingErr) || len(listingErr.Errors) != 1 || listingErr.Errors["hanging"] == nil {
+		t.Fatalf("expected only the hanging server to fail but found %v", err)
+	}
+	if len(tools) != 1 || tools[0].ServerName != "greetings" || tools[0].Name != "greet" {
+		t.Errorf("expected the tools of the other server but found %v", tools)
+	}
+
+	client, _ := host.GetClient(ctx, nil)
+	if tools, err := client.ListTools(ctx); !errors.As(err, &listingErr) || len(tools) != 1 {
+		t.Errorf("expected the client to list the tools it could along with the error; found %v, %v", tools, err)
+	}
+}
+
+func TestListAllToolsReportsServersThatAreNotReady(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{
+		{Name: "greetings", Command: "go", Args: []string{"run", "greetings.go"}, Cwd: "../../test_servers/greetings"},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	server, _ := host.getServer("greetings")
+
+	server.setState(StateFailed, errors.New("out of restarts"))
+	var listingErr *agent.ToolListingError
+	if tools, err := host.ListAllTools(ctx); !errors.As(err, &listingErr) || listingErr.Errors["greetings"] == nil || len(tools) != 0 {
+		t.Errorf("expected the failed server to be reported but found %v, %v", tools, err)
+	}
+
+	server.mu.Lock()
+	server.draining = true
+	server.mu.Unlock()
+	if tools, err := host.ListAllTools(ctx); err != nil || len(tools) != 0 {
+		t.Errorf("expected a server being removed to be left out silently but found %v, %v", tools, err)
+	}
+}
+
+func TestToolsOfSharedClientsAreNotCached(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:    "tools",
+		Command: "go",
+		Args:    []string{"run", "tools.go"},
+		Cwd:     "../../test_servers/tools",
+	}}}, host.newClient())
+	if err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	client, _ := host.GetClient(ctx, nil)
+
+	for range 2 {
+		toolNames(t, ctx, client)
+	}
+	if stats, _ := host.GetToolCacheStats("tools"); stats.Hits != 0 || stats.Misses != 2 {
+		t.Errorf("expected the tools to be listed each time but found %+v", stats)
+	}
+}
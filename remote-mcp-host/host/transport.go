@@ -0,0 +1,276 @@
+package host
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Opens and initializes a session with the server described by cfg.
+// The standard error of stdio servers is recorded in logs.
+func connectServer(ctx context.Context, client *mcp.Client, cfg *ServerConfig, logs *serverLogs) (*mcp.ClientSession, error) {
+	transport, err := newTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if command, ok := transport.(*mcp.CommandTransport); ok {
+		stderr, err := logs.captureStderr(command.Command)
+		if err != nil {
+			return nil, err
+		}
+		// Once the server has started, it holds the only writer.
+		defer stderr.Close()
+	}
+
+	if cfg.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cfg.ConnectTimeout))
+		defer cancel()
+	}
+
+	var watcher *initializeWatcher
+	if streamable, ok := transport.(*mcp.StreamableClientTransport); ok && cfg.sseFallbackEnabled() {
+		watcher = watchInitialize(streamable)
+	}
+
+	session, err := client.Connect(ctx, detachedTransport{transport}, nil)
+	if err != nil && watcher != nil && watcher.rejected() {
+		log.Printf("Server '%s' rejected streamable HTTP initialization with status %d; falling back to SSE", cfg.Name, watcher.initializeStatus())
+		sseCfg := *cfg
+		sseCfg.Transport = TransportSSE
+		if transport, err = newTransport(&sseCfg); err != nil {
+			return nil, err
+		}
+		session, err = client.Connect(ctx, detachedTransport{transport}, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to server '%s': %w", cfg.Name, err)
+	}
+	if err := setLogLevel(ctx, session, cfg); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func newTransport(cfg *ServerConfig) (mcp.Transport, error) {
+	switch cfg.Transport {
+	case TransportStdio:
+		cmd := exec.Command(cfg.Command, cfg.Args...)
+		cmd.Dir = cfg.Cwd
+		if len(cfg.Env) > 0 {
+			cmd.Env = append(os.Environ(), environ(cfg.Env)...)
+		}
+		return &mcp.CommandTransport{Command: cmd}, nil
+
+	case TransportHTTP:
+		httpClient, err := newHttpClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("configuring HTTP client for server '%s': %w", cfg.Name, err)
+		}
+		return &mcp.StreamableClientTransport{
+			Endpoint:   cfg.URL,
+			HTTPClient: httpClient,
+		}, nil
+
+	case TransportSSE:
+		httpClient, err := newHttpClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("configuring HTTP client for server '%s': %w", cfg.Name, err)
+		}
+		return &mcp.SSEClientTransport{
+			Endpoint:   cfg.URL,
+			HTTPClient: httpClient,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown transport %q for server '%s'", cfg.Transport, cfg.Name)
+}
+
+func environ(env map[string]string) []string {
+	vars := make([]string, 0, len(env))
+	for key, val := range env {
+		vars = append(vars, key+"="+val)
+	}
+	slices.Sort(vars)
+	return vars
+}
+
+// Builds the HTTP client used to reach the server, or returns nil if the default client suffices.
+func newHttpClient(cfg *ServerConfig) (*http.Client, error) {
+	headers := make(map[string]string, len(cfg.Headers)+1)
+	for key, val := range cfg.Headers {
+		headers[key] = val
+	}
+	if cfg.Auth != nil {
+		token, err := cfg.Auth.token()
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	if len(headers) == 0 && cfg.TLS == nil {
+		return nil, nil
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.clientConfig()
+		if err != nil {
+			return nil, err
+		}
+		httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+		httpTransport.TLSClientConfig = tlsConfig
+		transport = httpTransport
+	}
+
+	if len(headers) > 0 {
+		transport = &headerRoundTripper{headers: headers, next: transport}
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// Resolves the bearer token from whichever source is configured.
+func (a *AuthConfig) token() (string, error) {
+	switch {
+	case a.BearerTokenEnv != "":
+		token, ok := os.LookupEnv(a.BearerTokenEnv)
+		if !ok || token == "" {
+			return "", fmt.Errorf("environment variable %s for the bearer token is not set", a.BearerTokenEnv)
+		}
+		return token, nil
+	case a.BearerTokenFile != "":
+		data, err := os.ReadFile(a.BearerTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("reading bearer token: %w", err)
+		}
+		token := strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("bearer token file %s is empty", a.BearerTokenFile)
+		}
+		return token, nil
+	default:
+		return a.BearerToken, nil
+	}
+}
+
+func (c *TLSConfig) clientConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// headerRoundTripper adds static headers to every outgoing request.
+type headerRoundTripper struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (rt *headerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for key, val := range rt.headers {
+		req.Header.Set(key, val)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// initializeWatcher records the status of the first POST sent through a streamable HTTP
+// transport, which carries the initialize request.
+type initializeWatcher struct {
+	next   http.RoundTripper
+	mu     sync.Mutex
+	status int
+}
+
+func watchInitialize(transport *mcp.StreamableClientTransport) *initializeWatcher {
+	httpClient := transport.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	watcher := &initializeWatcher{next: next}
+	watchedClient := *httpClient
+	watchedClient.Transport = watcher
+	transport.HTTPClient = &watchedClient
+	return watcher
+}
+
+func (w *initializeWatcher) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := w.next.RoundTrip(req)
+	if err == nil && req.Method == http.MethodPost {
+		w.mu.Lock()
+		if w.status == 0 {
+			w.status = res.StatusCode
+		}
+		w.mu.Unlock()
+	}
+	return res, err
+}
+
+// Reports whether the server answered the initialize request in the way that,
+// per the MCP backwards compatibility guidance, identifies a server that only speaks HTTP+SSE.
+// Authentication failures are not taken as such.
+func (w *initializeWatcher) rejected() bool {
+	status := w.initializeStatus()
+	return status >= 400 && status < 500 &&
+		status != http.StatusUnauthorized && status != http.StatusForbidden
+}
+
+func (w *initializeWatcher) initializeStatus() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// detachedTransport connects its transport with a context that is not cancelled along with
+// the one passed to Connect, so that connection timeouts and request-scoped contexts only bound
+// initialization and not the lifetime of the session.
+type detachedTransport struct {
+	mcp.Transport
+}
+
+func (t detachedTransport) Connect(ctx context.Context) (mcp.Connection, error) {
+	return t.Transport.Connect(context.WithoutCancel(ctx))
+}
@@ -0,0 +1,157 @@
+package host
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type addInput struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+type addOutput struct {
+	Sum float64 `json:"sum"`
+}
+
+func newMathHandler() http.Handler {
+	server := mcp.NewServer(&mcp.Implementation{Name: "math", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "add", Description: "Adds two numbers"}, func(ctx context.Context, req *mcp.CallToolRequest, input addInput) (*mcp.CallToolResult, addOutput, error) {
+		return nil, addOutput{Sum: input.A + input.B}, nil
+	})
+	return mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil)
+}
+
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestHttpsServerWithBearerToken(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewTLSServer(requireBearerToken("secret", newMathHandler()))
+	defer ts.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPem, 0o600); err != nil {
+		t.Fatalf("could not write CA bundle: %s", err)
+	}
+	t.Setenv("MATH_TOKEN", "secret")
+
+	config := fmt.Sprintf(`
+servers:
+  - name: math
+    url: %s
+    auth:
+      bearerTokenEnv: MATH_TOKEN
+    tls:
+      caFile: %s
+`, ts.URL, caFile)
+
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	if err := host.AddSessionsFromConfig(ctx, strings.NewReader(config), nil); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+
+	tools, err := host.ListToolsOnServer(ctx, "math")
+	if err != nil {
+		t.Fatalf("could not list tools: %s", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "add" {
+		t.Errorf("expected the 'add' tool but found %v", tools)
+	}
+}
+
+func TestHttpsServerRejectsMissingToken(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewTLSServer(requireBearerToken("secret", newMathHandler()))
+	defer ts.Close()
+
+	host, _ := NewMcpHost(nil)
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name: "math",
+		URL:  ts.URL,
+		TLS:  &TLSConfig{InsecureSkipVerify: true},
+	}}}, nil)
+	if err == nil {
+		t.Fatalf("expected connecting without a bearer token to fail")
+	}
+	if len(host.ListServerNames()) != 0 {
+		t.Errorf("expected no servers to be added")
+	}
+}
+
+func newSSEMathHandler() http.Handler {
+	server := mcp.NewServer(&mcp.Implementation{Name: "math", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "add", Description: "Adds two numbers"}, func(ctx context.Context, req *mcp.CallToolRequest, input addInput) (*mcp.CallToolResult, addOutput, error) {
+		return nil, addOutput{Sum: input.A + input.B}, nil
+	})
+	return mcp.NewSSEHandler(func(r *http.Request) *mcp.Server { return server }, nil)
+}
+
+func TestSSEServer(t *testing.T) {
+	ctx := context.Background()
+
+	for _, transport := range []TransportKind{TransportSSE, TransportHTTP} {
+		t.Run(string(transport), func(t *testing.T) {
+			ts := httptest.NewServer(newSSEMathHandler())
+			defer ts.Close()
+
+			host, _ := NewMcpHost(nil)
+			defer host.Close()
+			err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+				Name:      "math",
+				Transport: transport,
+				URL:       ts.URL,
+			}}}, nil)
+			if err != nil {
+				t.Fatalf("could not add sessions: %s", err)
+			}
+
+			tools, err := host.ListToolsOnServer(ctx, "math")
+			if err != nil {
+				t.Fatalf("could not list tools: %s", err)
+			}
+			if len(tools) != 1 || tools[0].Name != "add" {
+				t.Errorf("expected the 'add' tool but found %v", tools)
+			}
+		})
+	}
+}
+
+func TestSSEFallbackDisabled(t *testing.T) {
+	ctx := context.Background()
+
+	ts := httptest.NewServer(newSSEMathHandler())
+	defer ts.Close()
+
+	disabled := false
+	host, _ := NewMcpHost(nil)
+	defer host.Close()
+	err := host.AddServers(ctx, &HostConfig{Servers: []ServerConfig{{
+		Name:        "math",
+		URL:         ts.URL,
+		SSEFallback: &disabled,
+	}}}, nil)
+	if err == nil {
+		t.Fatalf("expected connecting to an SSE server over streamable HTTP to fail")
+	}
+}
@@ -1,6 +1,10 @@
 package host
 
 import (
+	"sync"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -8,25 +12,109 @@ import (
 type ClientOptions struct {
 	ToolConfigs            []*api.ToolConfig
 	OnlyUseConfiguredTools bool
+	// Answers the elicitation requests that servers make while the client's tool calls are in flight.
+	// If nil, such requests fail.
+	Elicitor Elicitor
+	// Receives the progress reported for the client's tool calls.
+	// If nil, servers are not asked to report progress.
+	Progress ProgressReporter
 }
 
 type HostMcpClient struct {
 	host                   *McpHost
 	onlyUseConfiguredTools bool
 	toolConfigs            map[api.ToolId]api.ToolConfig
+	toolTimeouts           map[api.ToolId]time.Duration
+	elicitor               *clientElicitor
+	progress               ProgressReporter
 }
 
-type clientSessionWithName struct {
-	session     *mcp.ClientSession
-	sessionName string
-}
-
+// McpHost is safe for concurrent use.
 type McpHost struct {
-	sessions      map[string]*mcp.ClientSession
-	defaultClient *mcp.Client
-	opts          *McpHostOptions
+	// Guards sessions, but not the sessions themselves.
+	mu       *sync.RWMutex
+	sessions map[string]*serverSession
+	opts     *McpHostOptions
+
+	// Serializes ApplyConfig and guards the names of the servers it last applied.
+	reloadMu *sync.Mutex
+	applied  map[string]bool
+
+	// Guards the tool calls whose progress is reported, by progress token.
+	progressMu    *sync.Mutex
+	progressCalls map[string]*progressCall
 }
 
 type McpHostOptions struct {
-	_ bool
+	// Answers the sampling requests of servers whose configuration enables sampling.
+	// If nil, the host does not offer sampling.
+	Sampler agent.Agent
+	// Receives a record of every sampling request, whether or not it was answered.
+	// If nil, the records are logged.
+	SamplingAudit func(SamplingRecord)
+	// Receives a record of every tool call sent to a server.
+	// If nil, the calls that did not complete are logged.
+	ToolCallAudit func(ToolCallRecord)
+	// The number of log entries kept for each server. Defaults to 500.
+	ServerLogLimit int
+}
+
+// SamplingRecord describes a sampling request made by a server and how the host answered it.
+type SamplingRecord struct {
+	ServerName string
+	Time       time.Time
+	Duration   time.Duration
+	Request    *mcp.CreateMessageParams
+	// The result sent to the server, or nil if the request failed.
+	Result *mcp.CreateMessageResult
+	Error  string
+}
+
+// ToolCallOutcome is how a tool call sent to a server ended.
+type ToolCallOutcome string
+
+const (
+	ToolCallCompleted ToolCallOutcome = "completed"
+	// The call failed, or the tool reported an error.
+	ToolCallFailed ToolCallOutcome = "failed"
+	// The call took longer than its timeout.
+	ToolCallTimedOut ToolCallOutcome = "timed-out"
+	// The caller went away before the call finished, and the server was told to stop.
+	ToolCallCancelled ToolCallOutcome = "cancelled"
+)
+
+// ToolCallRecord describes a tool call sent to a server and how it ended.
+type ToolCallRecord struct {
+	ServerName string
+	ToolName   string
+	Time       time.Time
+	Duration   time.Duration
+	Outcome    ToolCallOutcome
+	Error      string
+}
+
+// ServerState is the lifecycle state of the session with an MCP server.
+type ServerState string
+
+const (
+	// The session is being opened or reopened.
+	StateConnecting ServerState = "connecting"
+	StateReady      ServerState = "ready"
+	// The session is open but the server is failing health checks.
+	StateDegraded ServerState = "degraded"
+	// The session could not be reopened and no further attempts are made.
+	StateFailed ServerState = "failed"
+	// The session was closed by the host.
+	StateStopped ServerState = "stopped"
+)
+
+type ServerStatus struct {
+	Name  string
+	State ServerState
+	// The most recent error, if the server is not ready.
+	Error string
+	// The number of times the session has been reopened.
+	Restarts int
+	// When the server entered its current state.
+	Since time.Time
 }
@@ -2,7 +2,11 @@ package impl
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
@@ -11,148 +15,91 @@ import (
 	"google.golang.org/genai"
 )
 
+// GeminiAgent generates with Google's Gemini models.
 type GeminiAgent struct {
+	agent.Loop
+}
+
+type geminiModel struct {
 	client *genai.Client
 	opts   *GeminiOpts
 }
 
-var GEMINI_MAX_REQUESTS_PER_ACT int = 3
-
-func (a GeminiAgent) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
-	var generatedParts []api.UnionPart
-	res, err := a.generate(ctx, client, messages, []api.UnionPart{}, &geminiConfig{})
-	if err != nil {
-		return nil, err
-	}
-	generatedParts = append(generatedParts, res.Parts...)
-
-	for i := 1; i < GEMINI_MAX_REQUESTS_PER_ACT && res.NumToolsCalled > 0; i++ {
-
-		if i == GEMINI_MAX_REQUESTS_PER_ACT-1 {
-			res, err = a.generate(ctx, nullClient{}, messages, generatedParts, &geminiConfig{
-				SystemInstruction: "The Responses from the tool calls are not visible to the user. Continue your response to the user based on the tool results in natural language. You must conclude your message to the user with this message.",
-			})
-		} else {
-			res, err = a.generate(ctx, client, messages, generatedParts, &geminiConfig{
-				SystemInstruction: "The Responses from the tool calls are not visible to the user. Continue your response to the user based on the tool results in natural language. You may call additional tools, but only if necessary.",
-			})
-		}
-
-		if err != nil {
-			return nil, err
-		}
-
-		generatedParts = append(generatedParts, res.Parts...)
-	}
-
-	return &agent.GenerateResult{
-		Message: api.NewModelMessage(generatedParts),
-	}, nil
+func (m geminiModel) ToolName(toolId api.ToolId) string {
+	return composeToolName(toolId)
 }
 
-func (a GeminiAgent) generate(ctx context.Context, client agent.McpClient, messages []api.Message, ammendedParts []api.UnionPart, config *geminiConfig) (*geminiGenerateResult, error) {
-	if config == nil {
-		config = &geminiConfig{}
-	}
-
-	combinedMessages := messages
-	if len(ammendedParts) > 0 {
-		combinedMessages = append(messages, *api.NewModelMessage(ammendedParts))
-
-	}
-	contents, err := messagesToGeminiContents(combinedMessages)
+func (m geminiModel) Generate(ctx context.Context, request *agent.ModelRequest, handler agent.EventHandler) (*agent.ModelResponse, error) {
+	contents, err := messagesToGeminiContents(request.Messages)
 	if err != nil {
 		return nil, err
 	}
 
-	serverTools, err := client.ListTools(ctx)
-	if err != nil {
-		return nil, err
+	opts := request.Options
+	generateConfig := &genai.GenerateContentConfig{
+		Tools:           modelToolsToGeminiTools(request.Tools),
+		MaxOutputTokens: int32(opts.MaxOutputTokens),
+		StopSequences:   opts.StopSequences,
 	}
-
-	tools := serverToolsToGeminiTools(serverTools)
-
-	res, err := a.client.Models.GenerateContent(ctx, a.opts.model, contents, &genai.GenerateContentConfig{
-		Tools: tools,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("getting response from Gemini: %s", err)
+	if opts.Temperature != nil {
+		generateConfig.Temperature = genai.Ptr(float32(*opts.Temperature))
 	}
-
-	var parts []api.UnionPart
-
-	var fullText string
-	{
-		var bldr strings.Builder
-		for _, part := range res.Candidates[0].Content.Parts {
-			bldr.WriteString(part.Text)
-		}
-		fullText = bldr.String()
+	if opts.TopP != nil {
+		generateConfig.TopP = genai.Ptr(float32(*opts.TopP))
 	}
-
-	if len(fullText) > 0 {
-		parts = append(parts, api.ToUnion(api.NewTextPart(fullText)))
+	if opts.Seed != nil {
+		generateConfig.Seed = genai.Ptr(int32(*opts.Seed))
+	}
+	if request.SystemPrompt != "" {
+		generateConfig.SystemInstruction = genai.NewContentFromText(request.SystemPrompt, genai.RoleUser)
 	}
 
-	numToolsCalled := 0
-	for _, call := range res.FunctionCalls() {
-		numToolsCalled += 1
-		toolRequest, err := geminiFunctionCallToServerToolRequest(call)
+	var bldr strings.Builder
+	var calls []agent.ModelToolCall
+	for chunk, err := range m.client.Models.GenerateContentStream(ctx, m.opts.Model, contents, generateConfig) {
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("getting response from Gemini: %s", err)
 		}
-
-		res, err := client.CallTool(ctx, toolRequest)
-		if err != nil {
-			parts = append(parts, api.ToUnion(api.NewToolUsePartError(toolRequest.Arguments, err.Error(), *toolRequest.ToolId())))
-			continue
+		if len(chunk.Candidates) > 0 && chunk.Candidates[0].Content != nil {
+			for _, part := range chunk.Candidates[0].Content.Parts {
+				if part.Text != "" && !part.Thought {
+					bldr.WriteString(part.Text)
+					handler.Emit(api.NewTextDeltaEvent(part.Text))
+				}
+			}
+		}
+		for _, call := range chunk.FunctionCalls() {
+			calls = append(calls, agent.ModelToolCall{Name: call.Name, Arguments: call.Args})
 		}
-		parts = append(parts, api.ToUnion(api.NewToolUsePart(toolRequest.Arguments, res.Output, res.ToolId)))
 	}
 
-	return &geminiGenerateResult{
-		Parts:          parts,
-		NumToolsCalled: numToolsCalled,
-	}, nil
-}
-
-type geminiGenerateResult struct {
-	Parts          []api.UnionPart
-	NumToolsCalled int
-}
-
-type geminiConfig struct {
-	SystemInstruction string
+	return &agent.ModelResponse{Text: bldr.String(), ToolCalls: calls}, nil
 }
 
 func NewGeminiAgent(ctx context.Context, opts *GeminiOpts) (*GeminiAgent, error) {
 
 	if opts == nil {
-		opts = &GeminiOpts{model: "gemini-2.0-flash"}
+		opts = &GeminiOpts{}
+	}
+	if opts.Model == "" {
+		opts = &GeminiOpts{Model: GEMINI_DEFAULT_MODEL}
 	}
 
 	client, err := genai.NewClient(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	return &GeminiAgent{
-		client: client,
-		opts:   opts,
-	}, nil
+	return &GeminiAgent{agent.Loop{
+		Model:     geminiModel{client: client, opts: opts},
+		ModelName: opts.Model,
+	}}, nil
 }
 
-type GeminiOpts struct {
-	model string
-}
-
-type nullClient struct{}
-
-func (n nullClient) ListTools(ctx context.Context) ([]*agent.ServerTool, error) {
-	return []*agent.ServerTool{}, nil
-}
+var GEMINI_DEFAULT_MODEL = "gemini-2.0-flash"
 
-func (n nullClient) CallTool(ctx context.Context, toolRequest *agent.ServerToolRequest) (*api.ToolUsePart, error) {
-	return nil, fmt.Errorf("no tools are available")
+type GeminiOpts struct {
+	// The model to generate with. Defaults to GEMINI_DEFAULT_MODEL.
+	Model string
 }
 
 func messagesToGeminiContents(messages []api.Message) ([]*genai.Content, error) {
@@ -167,6 +114,13 @@ func messagesToGeminiContents(messages []api.Message) ([]*genai.Content, error)
 			switch part := part.Part.(type) {
 			case api.TextPart:
 				parts = append(parts, genai.NewPartFromText(part.Text))
+			case api.ResourcePart:
+				parts = append(parts, genai.NewPartFromText(resourceIntro(part)))
+				if len(part.Blob) > 0 {
+					parts = append(parts, genai.NewPartFromBytes(part.Blob, part.MimeType))
+				} else {
+					parts = append(parts, genai.NewPartFromText(resourceText(part)))
+				}
 			case api.ToolUsePart:
 				name := composeToolName(part.ToolId)
 				args, ok := part.Input.(map[string]any)
@@ -207,52 +161,82 @@ func messagesToGeminiContents(messages []api.Message) ([]*genai.Content, error)
 	return contents, nil
 }
 
-func serverToolToGeminiTool(serverTool *agent.ServerTool) *genai.Tool {
-	return &genai.Tool{
-		FunctionDeclarations: []*genai.FunctionDeclaration{{
-			Description: serverTool.Description,
-			Name: composeToolName(
-				api.ToolId{Name: serverTool.Name, ServerName: serverTool.ServerName}),
-			ParametersJsonSchema: serverTool.InputSchema,
-			ResponseJsonSchema:   serverTool.OutputSchema}}}
-}
-
-func serverToolsToGeminiTools(serverTools []*agent.ServerTool) []*genai.Tool {
+func modelToolsToGeminiTools(modelTools []agent.ModelTool) []*genai.Tool {
 	var tools []*genai.Tool
 
-	for _, t := range serverTools {
-		tools = append(tools, serverToolToGeminiTool(t))
+	for _, t := range modelTools {
+		tools = append(tools, &genai.Tool{
+			FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Description:          t.Description,
+				Name:                 t.Name,
+				ParametersJsonSchema: t.InputSchema,
+				ResponseJsonSchema:   t.OutputSchema}}})
 	}
 
 	return tools
 }
 
-func geminiFunctionCallToServerToolRequest(call *genai.FunctionCall) (*agent.ServerToolRequest, error) {
+func composeToolName(toolId api.ToolId) string {
+	if toolId.ServerName == "" {
+		// An unknown tool that the model called.
+		return toolId.Name
+	}
+	return toolId.ServerName + "." + toolId.Name
+}
 
-	toolId, err := toolIdFromCompositeName(call.Name)
+// Gets the output of a tool use as text: its structured content or error as JSON, or else its text content.
+func toolUseOutputText(part api.ToolUsePart) (string, error) {
+	var output any = map[string]any{"error": part.Error}
+	if part.Output.StructuredContent != nil {
+		output = part.Output.StructuredContent
+	} else if part.Error == "" {
+		var texts []string
+		for _, content := range part.Output.Content {
+			if text, ok := content.(*mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), nil
+	}
+	data, err := json.Marshal(output)
 	if err != nil {
-		return nil, fmt.Errorf("syntactically invalid toolId used '%s'", call.Name)
+		return "", fmt.Errorf("invalid output of tool use '%v': %s", part.Output, err)
 	}
-	return &agent.ServerToolRequest{
-		ServerName: toolId.ServerName,
-		CallToolParams: mcp.CallToolParams{
-			Name:      toolId.Name,
-			Arguments: call.Args,
-		},
-	}, nil
+	return string(data), nil
 }
 
-func composeToolName(toolId api.ToolId) string {
-	return toolId.ServerName + "." + toolId.Name
+// Introduces the contents of a resource to the model.
+func resourceIntro(part api.ResourcePart) string {
+	return fmt.Sprintf("The contents of resource %s from server '%s' follow.", part.URI, part.ServerName)
 }
 
-func toolIdFromCompositeName(name string) (*api.ToolId, error) {
-	parts := strings.Split(name, ".")
-	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
-		return nil, fmt.Errorf("invalid composite tool name '%s'", name)
+// Gets the contents of a resource as text, for models that cannot be given its blob:
+// its text, or else a note of the blob's size and type.
+func resourceText(part api.ResourcePart) string {
+	if len(part.Blob) > 0 {
+		return fmt.Sprintf("(%d bytes of type %s that cannot be shown)", len(part.Blob), part.MimeType)
 	}
-	return &api.ToolId{
-		ServerName: parts[0],
-		Name:       parts[1],
-	}, nil
+	return part.Text
+}
+
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// Names a tool for providers whose tool names may only hold letters, digits, '_' and '-',
+// and at most 64 of them. The server and tool names are joined with "__". If the names cannot
+// be read back from the result, because characters were replaced, the name was cut or the names
+// hold "__" themselves, a hash of the tool ID is appended so that different tools keep different names.
+func sanitizedToolName(toolId api.ToolId) string {
+	if toolId.ServerName == "" {
+		// An unknown tool that the model called.
+		return toolId.Name
+	}
+	joined := toolId.ServerName + "__" + toolId.Name
+	name := invalidToolNameChars.ReplaceAllString(joined, "_")
+	serverName, toolName, _ := strings.Cut(name, "__")
+	if name == joined && len(name) <= 64 && serverName == toolId.ServerName && toolName == toolId.Name {
+		return name
+	}
+	sum := sha256.Sum256([]byte(toolId.ServerName + "\x00" + toolId.Name))
+	suffix := "_" + hex.EncodeToString(sum[:4])
+	return name[:min(len(name), 64-len(suffix))] + suffix
 }
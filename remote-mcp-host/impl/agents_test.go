@@ -0,0 +1,35 @@
+package impl
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+func TestSanitizedToolNamesAreUnique(t *testing.T) {
+	long := strings.Repeat("x", 70)
+	for _, pair := range [][2]api.ToolId{
+		// Replaced characters.
+		{{ServerName: "files", Name: "read.file"}, {ServerName: "files", Name: "read_file"}},
+		// Names that hold the separator.
+		{{ServerName: "a__b", Name: "c"}, {ServerName: "a", Name: "b__c"}},
+		// Names that are cut.
+		{{ServerName: "s", Name: long + "1"}, {ServerName: "s", Name: long + "2"}},
+	} {
+		first, second := sanitizedToolName(pair[0]), sanitizedToolName(pair[1])
+		if first == second {
+			t.Errorf("expected %v and %v to be named differently but both were '%s'", pair[0], pair[1], first)
+		}
+		for _, name := range []string{first, second} {
+			if len(name) > 64 || !regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString(name) {
+				t.Errorf("expected a valid tool name but found '%s'", name)
+			}
+		}
+	}
+
+	if name := sanitizedToolName(api.ToolId{ServerName: "greetings", Name: "greet"}); name != "greetings__greet" {
+		t.Errorf("expected names that need no change to be kept but found '%s'", name)
+	}
+}
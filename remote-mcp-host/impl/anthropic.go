@@ -0,0 +1,308 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// AnthropicAgent generates with the Anthropic Messages API.
+// As the API accepts temperatures between 0 and 1 only, higher temperatures are lowered to 1.
+type AnthropicAgent struct {
+	agent.Loop
+}
+
+var ANTHROPIC_DEFAULT_MODEL = "claude-sonnet-4-5"
+
+var ANTHROPIC_DEFAULT_BASE_URL = "https://api.anthropic.com"
+
+// The API requires a limit on the tokens to generate, so one is sent when the options have none.
+var ANTHROPIC_DEFAULT_MAX_TOKENS = 4096
+
+const anthropicVersion = "2023-06-01"
+
+type AnthropicOpts struct {
+	// The model to generate with. Defaults to ANTHROPIC_DEFAULT_MODEL.
+	Model string
+	// The URL under which the messages endpoint is found.
+	// Defaults to the ANTHROPIC_BASE_URL environment variable or else ANTHROPIC_DEFAULT_BASE_URL.
+	BaseURL string
+	// The key sent in the x-api-key header. Defaults to the ANTHROPIC_API_KEY environment variable.
+	APIKey string
+	// The client with which requests are made. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewAnthropicAgent(opts *AnthropicOpts) (*AnthropicAgent, error) {
+	o := AnthropicOpts{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Model == "" {
+		o.Model = ANTHROPIC_DEFAULT_MODEL
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = os.Getenv("ANTHROPIC_BASE_URL")
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = ANTHROPIC_DEFAULT_BASE_URL
+	}
+	if o.APIKey == "" {
+		o.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return &AnthropicAgent{agent.Loop{Model: anthropicModel{opts: &o}, ModelName: o.Model}}, nil
+}
+
+type anthropicModel struct {
+	opts *AnthropicOpts
+}
+
+func (m anthropicModel) ToolName(toolId api.ToolId) string {
+	return sanitizedToolName(toolId)
+}
+
+func (m anthropicModel) Generate(ctx context.Context, request *agent.ModelRequest, handler agent.EventHandler) (*agent.ModelResponse, error) {
+	anthropicMessages, err := messagesToAnthropicMessages(request.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []anthropicTool
+	for _, t := range request.Tools {
+		tools = append(tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: t.InputSchema})
+	}
+
+	// The API has no seed, so it is left out.
+	opts := request.Options
+	maxTokens := opts.MaxOutputTokens
+	if maxTokens == 0 {
+		maxTokens = ANTHROPIC_DEFAULT_MAX_TOKENS
+	}
+	temperature := opts.Temperature
+	if temperature != nil && *temperature > 1 {
+		highest := 1.0
+		temperature = &highest
+	}
+	content, calls, err := m.complete(ctx, &anthropicRequest{
+		Model:         m.opts.Model,
+		System:        request.SystemPrompt,
+		Messages:      anthropicMessages,
+		Tools:         tools,
+		MaxTokens:     maxTokens,
+		Temperature:   temperature,
+		TopP:          opts.TopP,
+		StopSequences: opts.StopSequences,
+		Stream:        true,
+	}, handler)
+	if err != nil {
+		return nil, fmt.Errorf("getting response from Anthropic: %s", err)
+	}
+
+	res := &agent.ModelResponse{Text: content}
+	for _, call := range calls {
+		args := map[string]any{}
+		if input, _ := call.Input.(json.RawMessage); len(strings.TrimSpace(string(input))) > 0 {
+			if err := json.Unmarshal(input, &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool '%s': %s", call.Name, err)
+			}
+		}
+		res.ToolCalls = append(res.ToolCalls, agent.ModelToolCall{Name: call.Name, Arguments: args})
+	}
+	return res, nil
+}
+
+// Sends the request and reads the streamed response, passing text deltas to the handler.
+// Returns the generated text and the tool_use blocks, whose input is left as JSON text.
+func (m anthropicModel) complete(ctx context.Context, request *anthropicRequest, handler agent.EventHandler) (string, []anthropicBlock, error) {
+	header := http.Header{"Accept": {"text/event-stream"}, "Anthropic-Version": {anthropicVersion}}
+	if m.opts.APIKey != "" {
+		header.Set("X-Api-Key", m.opts.APIKey)
+	}
+	body, err := postJson(ctx, m.opts.HTTPClient, strings.TrimSuffix(m.opts.BaseURL, "/")+"/v1/messages", header, request)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	var bldr strings.Builder
+	var calls []anthropicBlock
+	// The JSON input of each tool_use block arrives in fragments, keyed by the block's index.
+	inputs := make(map[int]*strings.Builder)
+	err = eachEventData(body, func(data []byte) error {
+		var event anthropicEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return fmt.Errorf("invalid event '%s': %s", data, err)
+		}
+		switch event.Type {
+		case "error":
+			return errors.New(event.Error.Message)
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				inputs[event.Index] = &strings.Builder{}
+				calls = append(calls, anthropicBlock{Type: "tool_use", Id: event.ContentBlock.Id, Name: event.ContentBlock.Name})
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				bldr.WriteString(event.Delta.Text)
+				handler.Emit(api.NewTextDeltaEvent(event.Delta.Text))
+			case "input_json_delta":
+				if input, ok := inputs[event.Index]; ok {
+					input.WriteString(event.Delta.PartialJson)
+				}
+			}
+		case "content_block_stop":
+			if input, ok := inputs[event.Index]; ok {
+				calls[len(calls)-1].Input = json.RawMessage(input.String())
+				delete(inputs, event.Index)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return bldr.String(), calls, nil
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	Tools         []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a content block of any type; only the fields of its type are set.
+type anthropicBlock struct {
+	Type string `json:"type"`
+	// Set for text blocks.
+	Text string `json:"text,omitempty"`
+	// Set for image blocks.
+	Source *anthropicSource `json:"source,omitempty"`
+	// Set for tool_use blocks.
+	Id    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Input any    `json:"input,omitempty"`
+	// Set for tool_result blocks.
+	ToolUseId string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+type anthropicSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      []byte `json:"data"`
+}
+
+type anthropicTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+type anthropicEvent struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	ContentBlock struct {
+		Type string `json:"type"`
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJson string `json:"partial_json"`
+	} `json:"delta"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Converts the messages, answering each tool_use block with a tool_result block in a user message.
+// Consecutive messages of the same role are merged, since the API expects the roles to alternate.
+func messagesToAnthropicMessages(messages []api.Message) ([]anthropicMessage, error) {
+
+	var anthropicMessages []anthropicMessage
+	add := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(anthropicMessages); n > 0 && anthropicMessages[n-1].Role == role {
+			anthropicMessages[n-1].Content = append(anthropicMessages[n-1].Content, blocks...)
+			return
+		}
+		anthropicMessages = append(anthropicMessages, anthropicMessage{Role: role, Content: blocks})
+	}
+	numToolUses := 0
+
+	for _, message := range messages {
+		role := "user"
+		if message.Role == "model" {
+			role = "assistant"
+		}
+
+		for _, part := range message.Parts {
+
+			switch part := part.Part.(type) {
+			case api.TextPart:
+				// Empty text blocks are rejected.
+				if part.Text != "" {
+					add(role, anthropicBlock{Type: "text", Text: part.Text})
+				}
+			case api.ResourcePart:
+				add(role, anthropicBlock{Type: "text", Text: resourceIntro(part)})
+				if len(part.Blob) > 0 && strings.HasPrefix(part.MimeType, "image/") {
+					add(role, anthropicBlock{Type: "image", Source: &anthropicSource{Type: "base64", MediaType: part.MimeType, Data: part.Blob}})
+				} else if text := resourceText(part); text != "" {
+					// As with text parts, an empty resource is left out.
+					add(role, anthropicBlock{Type: "text", Text: text})
+				}
+			case api.ToolUsePart:
+				output, err := toolUseOutputText(part)
+				if err != nil {
+					return nil, err
+				}
+				input := part.Input
+				if input == nil {
+					input = map[string]any{}
+				}
+				numToolUses += 1
+				id := fmt.Sprintf("toolu_%d", numToolUses)
+
+				add("assistant", anthropicBlock{Type: "tool_use", Id: id, Name: sanitizedToolName(part.ToolId), Input: input})
+				add("user", anthropicBlock{
+					Type:      "tool_result",
+					ToolUseId: id,
+					Content:   output,
+					IsError:   part.Error != "" || part.Output.IsError,
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid part type '%v'", part)
+			}
+		}
+	}
+
+	return anthropicMessages, nil
+}
@@ -0,0 +1,144 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// The request as the fake API reads it back.
+type anthropicTestRequest struct {
+	Model       string   `json:"model"`
+	System      string   `json:"system"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature *float64 `json:"temperature"`
+	Messages    []struct {
+		Role    string           `json:"role"`
+		Content []map[string]any `json:"content"`
+	} `json:"messages"`
+	Tools []anthropicTool `json:"tools"`
+}
+
+// Serves the messages endpoint, answering each request with the next of the streamed responses.
+func newAnthropicServer(t *testing.T, responses ...[]string) (*httptest.Server, *[]anthropicTestRequest) {
+	var requests []anthropicTestRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("X-Api-Key") != "key" || r.Header.Get("Anthropic-Version") == "" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var request anthropicTestRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, request)
+		if len(requests) > len(responses) {
+			http.Error(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, 529)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range responses[len(requests)-1] {
+			var e struct{ Type string }
+			json.Unmarshal([]byte(event), &e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, event)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestAnthropicAgentCallsTools(t *testing.T) {
+	ts, requests := newAnthropicServer(t,
+		[]string{
+			`{"type":"message_start","message":{"id":"msg_1","role":"assistant","content":[]}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me "}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"greet."}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_abc","name":"greetings__greet","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"na"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"me\":\"Ada\"}"}}`,
+			`{"type":"content_block_stop","index":1}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+			`{"type":"message_stop"}`,
+		},
+		[]string{
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Ada was greeted."}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_stop"}`,
+		},
+	)
+	a, _ := NewAnthropicAgent(&AnthropicOpts{Model: "stand-in", BaseURL: ts.URL, APIKey: "key"})
+	client := &greetingClient{}
+
+	// Above the highest temperature that the API accepts.
+	temperature := 1.5
+	var deltas []string
+	res, err := a.Stream(context.Background(), client, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Greet Ada"))},
+	}}, &agent.GenerateOptions{SystemPrompt: "Be brief.", Temperature: &temperature}, func(event api.GenerationEvent) {
+		if event.Type == "text-delta" {
+			deltas = append(deltas, event.Text)
+		}
+	})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(client.requests) != 1 || client.requests[0].ServerName != "greetings" || client.requests[0].Arguments.(map[string]any)["name"] != "Ada" {
+		t.Fatalf("expected greet to be called once with the name but found %v", client.requests)
+	}
+	if strings.Join(deltas, "") != "Let me greet.Ada was greeted." {
+		t.Errorf("expected the text to be streamed but found %q", deltas)
+	}
+	if res.Model != "stand-in" || len(res.Message.Parts) != 3 {
+		t.Fatalf("expected the text, tool use and answer but found %+v", res)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected two requests but found %d", len(*requests))
+	}
+	first := (*requests)[0]
+	if first.Model != "stand-in" || first.MaxTokens != ANTHROPIC_DEFAULT_MAX_TOKENS || first.System != "Be brief." || first.Temperature == nil || *first.Temperature != 1 {
+		t.Errorf("expected the options to be sent but found %+v", first)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].Name != "greetings__greet" {
+		t.Errorf("expected the tool to be declared but found %+v", first.Tools)
+	}
+
+	second := (*requests)[1]
+	if len(second.Messages) != 3 || second.Messages[1].Role != "assistant" || second.Messages[2].Role != "user" {
+		t.Fatalf("expected the tool use and its result to be sent back but found %+v", second.Messages)
+	}
+	use := second.Messages[1].Content[1]
+	result := second.Messages[2].Content[0]
+	if use["type"] != "tool_use" || use["name"] != "greetings__greet" || use["input"].(map[string]any)["name"] != "Ada" {
+		t.Errorf("expected a tool_use block but found %v", use)
+	}
+	if result["type"] != "tool_result" || result["tool_use_id"] != use["id"] || result["content"] != `{"greeting":"Hi Ada"}` {
+		t.Errorf("expected a tool_result block answering the tool use but found %v", result)
+	}
+}
+
+func TestAnthropicAgentReportsErrors(t *testing.T) {
+	ts, _ := newAnthropicServer(t)
+	a, _ := NewAnthropicAgent(&AnthropicOpts{BaseURL: ts.URL, APIKey: "key"})
+
+	_, err := a.Act(context.Background(), &greetingClient{}, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Hello"))},
+	}}, &agent.GenerateOptions{MaxOutputTokens: 10})
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("expected the error of the API but found %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// OllamaAgent generates with the chat endpoint of a local Ollama server, so no internet access is needed.
+type OllamaAgent struct {
+	agent.Loop
+}
+
+var OLLAMA_DEFAULT_MODEL = "llama3.2"
+
+var OLLAMA_DEFAULT_BASE_URL = "http://localhost:11434"
+
+type OllamaOpts struct {
+	// The model to generate with, which must support tool calling. Defaults to OLLAMA_DEFAULT_MODEL.
+	Model string
+	// The URL of the Ollama server. Defaults to the OLLAMA_HOST environment variable or else OLLAMA_DEFAULT_BASE_URL.
+	BaseURL string
+	// How long the server keeps the model loaded after a request. Negative keeps it loaded
+	// indefinitely and zero leaves it to the server's default.
+	KeepAlive time.Duration
+	// The client with which requests are made. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewOllamaAgent(opts *OllamaOpts) (*OllamaAgent, error) {
+	o := OllamaOpts{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Model == "" {
+		o.Model = OLLAMA_DEFAULT_MODEL
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = os.Getenv("OLLAMA_HOST")
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = OLLAMA_DEFAULT_BASE_URL
+	}
+	if !strings.Contains(o.BaseURL, "://") {
+		// OLLAMA_HOST is commonly given as a bare host and port.
+		o.BaseURL = "http://" + o.BaseURL
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return &OllamaAgent{agent.Loop{Model: ollamaModel{opts: &o}, ModelName: o.Model}}, nil
+}
+
+type ollamaModel struct {
+	opts *OllamaOpts
+}
+
+func (m ollamaModel) ToolName(toolId api.ToolId) string {
+	return sanitizedToolName(toolId)
+}
+
+func (m ollamaModel) Generate(ctx context.Context, request *agent.ModelRequest, handler agent.EventHandler) (*agent.ModelResponse, error) {
+	chatMessages, err := messagesToOllamaMessages(request.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if request.SystemPrompt != "" {
+		chatMessages = append([]ollamaMessage{{Role: "system", Content: request.SystemPrompt}}, chatMessages...)
+	}
+
+	var tools []ollamaTool
+	for _, t := range request.Tools {
+		tools = append(tools, ollamaTool{
+			Type:     "function",
+			Function: ollamaFunctionDecl{Name: t.Name, Description: t.Description, Parameters: t.InputSchema},
+		})
+	}
+
+	chatRequest := &ollamaRequest{
+		Model:    m.opts.Model,
+		Messages: chatMessages,
+		Tools:    tools,
+		Stream:   true,
+	}
+	if m.opts.KeepAlive != 0 {
+		chatRequest.KeepAlive = m.opts.KeepAlive.String()
+	}
+	if opts := request.Options; opts.MaxOutputTokens > 0 || opts.Temperature != nil || opts.TopP != nil || len(opts.StopSequences) > 0 || opts.Seed != nil {
+		chatRequest.Options = &ollamaModelOptions{
+			NumPredict:  opts.MaxOutputTokens,
+			Temperature: opts.Temperature,
+			TopP:        opts.TopP,
+			Stop:        opts.StopSequences,
+			Seed:        opts.Seed,
+		}
+	}
+	content, calls, err := m.complete(ctx, chatRequest, handler)
+	if err != nil {
+		return nil, fmt.Errorf("getting response from Ollama: %s", err)
+	}
+
+	res := &agent.ModelResponse{Text: content}
+	for _, call := range calls {
+		res.ToolCalls = append(res.ToolCalls, agent.ModelToolCall{Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	return res, nil
+}
+
+// Sends the request and reads the streamed response, passing text deltas to the handler.
+// Returns the generated text and the tool calls that the model made.
+func (m ollamaModel) complete(ctx context.Context, request *ollamaRequest, handler agent.EventHandler) (string, []ollamaToolCall, error) {
+	body, err := postJson(ctx, m.opts.HTTPClient, strings.TrimSuffix(m.opts.BaseURL, "/")+"/api/chat", nil, request)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	var bldr strings.Builder
+	var calls []ollamaToolCall
+	err = eachJsonLine(body, func(line []byte) error {
+		var chunk ollamaChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("invalid chunk '%s': %s", line, err)
+		}
+		if chunk.Error != "" {
+			return errors.New(chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			bldr.WriteString(chunk.Message.Content)
+			handler.Emit(api.NewTextDeltaEvent(chunk.Message.Content))
+		}
+		// Unlike text, each tool call arrives whole.
+		calls = append(calls, chunk.Message.ToolCalls...)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return bldr.String(), calls, nil
+}
+
+type ollamaRequest struct {
+	Model     string              `json:"model"`
+	Messages  []ollamaMessage     `json:"messages"`
+	Tools     []ollamaTool        `json:"tools,omitempty"`
+	Stream    bool                `json:"stream"`
+	KeepAlive string              `json:"keep_alive,omitempty"`
+	Options   *ollamaModelOptions `json:"options,omitempty"`
+}
+
+type ollamaModelOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Images given to the model, as base64-encoded by encoding/json.
+	Images    [][]byte         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	// The tool whose result a tool message holds.
+	ToolName string `json:"tool_name,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaFunctionDecl `json:"function"`
+}
+
+type ollamaFunctionDecl struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChunk struct {
+	Message ollamaMessage `json:"message"`
+	Done    bool          `json:"done"`
+	Error   string        `json:"error"`
+}
+
+func messagesToOllamaMessages(messages []api.Message) ([]ollamaMessage, error) {
+
+	var chatMessages []ollamaMessage
+
+	for _, message := range messages {
+		role := "user"
+		if message.Role == "model" {
+			role = "assistant"
+		}
+		var texts []string
+		var images [][]byte
+
+		for _, part := range message.Parts {
+
+			switch part := part.Part.(type) {
+			case api.TextPart:
+				texts = append(texts, part.Text)
+			case api.ResourcePart:
+				texts = append(texts, resourceIntro(part))
+				if len(part.Blob) > 0 && strings.HasPrefix(part.MimeType, "image/") {
+					images = append(images, part.Blob)
+				} else {
+					texts = append(texts, resourceText(part))
+				}
+			case api.ToolUsePart:
+				output, err := toolUseOutputText(part)
+				if err != nil {
+					return nil, err
+				}
+				name := sanitizedToolName(part.ToolId)
+				call := ollamaToolCall{}
+				call.Function.Name = name
+				call.Function.Arguments, _ = part.Input.(map[string]any)
+
+				chatMessages = append(chatMessages, ollamaMessage{
+					Role:      "assistant",
+					Content:   strings.Join(texts, "\n"),
+					Images:    images,
+					ToolCalls: []ollamaToolCall{call},
+				})
+				texts, images = nil, nil
+
+				chatMessages = append(chatMessages, ollamaMessage{
+					Role:     "tool",
+					Content:  output,
+					ToolName: name,
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid part type '%v'", part)
+			}
+		}
+
+		if len(texts) > 0 || len(images) > 0 {
+			chatMessages = append(chatMessages, ollamaMessage{
+				Role:    role,
+				Content: strings.Join(texts, "\n"),
+				Images:  images,
+			})
+		}
+	}
+
+	return chatMessages, nil
+}
@@ -0,0 +1,112 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// Serves the chat endpoint, answering each request with the next of the streamed responses.
+func newOllamaServer(t *testing.T, responses ...[]string) (*httptest.Server, *[]ollamaRequest) {
+	var requests []ollamaRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.Error(w, "unexpected request", http.StatusNotFound)
+			return
+		}
+		var request ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, request)
+		if len(requests) > len(responses) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"model \"missing\" not found, try pulling it first"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, chunk := range responses[len(requests)-1] {
+			fmt.Fprintln(w, chunk)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestOllamaAgentCallsTools(t *testing.T) {
+	ts, requests := newOllamaServer(t,
+		[]string{
+			`{"message":{"role":"assistant","content":"Let me greet."},"done":false}`,
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"greetings__greet","arguments":{"name":"Ada"}}}]},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true}`,
+		},
+		[]string{
+			`{"message":{"role":"assistant","content":"Ada was "},"done":false}`,
+			`{"message":{"role":"assistant","content":"greeted."},"done":true}`,
+		},
+	)
+	a, _ := NewOllamaAgent(&OllamaOpts{Model: "stand-in", BaseURL: ts.URL, KeepAlive: 10 * time.Minute})
+	client := &greetingClient{}
+
+	var deltas []string
+	res, err := a.Stream(context.Background(), client, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Greet Ada"))},
+	}}, &agent.GenerateOptions{MaxOutputTokens: 100}, func(event api.GenerationEvent) {
+		if event.Type == "text-delta" {
+			deltas = append(deltas, event.Text)
+		}
+	})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(client.requests) != 1 || client.requests[0].ServerName != "greetings" || client.requests[0].Arguments.(map[string]any)["name"] != "Ada" {
+		t.Fatalf("expected greet to be called once with the name but found %v", client.requests)
+	}
+	if strings.Join(deltas, "") != "Let me greet.Ada was greeted." {
+		t.Errorf("expected the text to be streamed but found %q", deltas)
+	}
+	if res.Model != "stand-in" || len(res.Message.Parts) != 3 {
+		t.Fatalf("expected the text, tool use and answer but found %+v", res)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected two requests but found %d", len(*requests))
+	}
+	first := (*requests)[0]
+	if first.Model != "stand-in" || first.KeepAlive != "10m0s" || first.Options == nil || first.Options.NumPredict != 100 {
+		t.Errorf("expected the options to be sent but found %+v", first)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != "greetings__greet" {
+		t.Errorf("expected the tool to be declared but found %+v", first.Tools)
+	}
+	second := (*requests)[1]
+	last := second.Messages[len(second.Messages)-1]
+	call := second.Messages[len(second.Messages)-2]
+	if last.Role != "tool" || last.Content != `{"greeting":"Hi Ada"}` || last.ToolName != "greetings__greet" || len(call.ToolCalls) != 1 {
+		t.Errorf("expected the tool call and its result to be sent back but found %+v", second.Messages)
+	}
+}
+
+func TestOllamaAgentReportsErrors(t *testing.T) {
+	ts, _ := newOllamaServer(t)
+	a, _ := NewOllamaAgent(&OllamaOpts{Model: "missing", BaseURL: ts.URL})
+
+	_, err := a.Act(context.Background(), &greetingClient{}, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Hello"))},
+	}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("expected the error of the server but found %v", err)
+	}
+}
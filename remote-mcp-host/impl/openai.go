@@ -0,0 +1,284 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// OpenAIAgent generates with any endpoint that speaks the OpenAI Chat Completions protocol,
+// such as OpenAI itself, vLLM, the llama.cpp server or LM Studio.
+type OpenAIAgent struct {
+	agent.Loop
+}
+
+var OPENAI_DEFAULT_MODEL = "gpt-4o-mini"
+
+var OPENAI_DEFAULT_BASE_URL = "https://api.openai.com/v1"
+
+type OpenAIOpts struct {
+	// The model to generate with. Defaults to OPENAI_DEFAULT_MODEL.
+	Model string
+	// The URL under which the chat completions endpoint is found, e.g. "http://localhost:8000/v1".
+	// Defaults to the OPENAI_BASE_URL environment variable or else OPENAI_DEFAULT_BASE_URL.
+	BaseURL string
+	// The key sent as a bearer token. Defaults to the OPENAI_API_KEY environment variable.
+	// Local endpoints often need none.
+	APIKey string
+	// The client with which requests are made. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func NewOpenAIAgent(opts *OpenAIOpts) (*OpenAIAgent, error) {
+	o := OpenAIOpts{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Model == "" {
+		o.Model = OPENAI_DEFAULT_MODEL
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = os.Getenv("OPENAI_BASE_URL")
+	}
+	if o.BaseURL == "" {
+		o.BaseURL = OPENAI_DEFAULT_BASE_URL
+	}
+	if o.APIKey == "" {
+		o.APIKey = os.Getenv("OPENAI_API_KEY")
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return &OpenAIAgent{agent.Loop{Model: openaiModel{opts: &o}, ModelName: o.Model}}, nil
+}
+
+type openaiModel struct {
+	opts *OpenAIOpts
+}
+
+func (m openaiModel) ToolName(toolId api.ToolId) string {
+	return sanitizedToolName(toolId)
+}
+
+func (m openaiModel) Generate(ctx context.Context, request *agent.ModelRequest, handler agent.EventHandler) (*agent.ModelResponse, error) {
+	chatMessages, err := messagesToOpenAIMessages(request.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if request.SystemPrompt != "" {
+		chatMessages = append([]openaiMessage{{Role: "system", Content: request.SystemPrompt}}, chatMessages...)
+	}
+
+	var tools []openaiTool
+	for _, t := range request.Tools {
+		tools = append(tools, openaiTool{
+			Type:     "function",
+			Function: openaiFunctionDecl{Name: t.Name, Description: t.Description, Parameters: t.InputSchema},
+		})
+	}
+
+	opts := request.Options
+	content, calls, err := m.complete(ctx, &openaiRequest{
+		Model:       m.opts.Model,
+		Messages:    chatMessages,
+		Tools:       tools,
+		MaxTokens:   opts.MaxOutputTokens,
+		Temperature: opts.Temperature,
+		TopP:        opts.TopP,
+		Stop:        opts.StopSequences,
+		Seed:        opts.Seed,
+		Stream:      true,
+	}, handler)
+	if err != nil {
+		return nil, fmt.Errorf("getting response from OpenAI endpoint: %s", err)
+	}
+
+	res := &agent.ModelResponse{Text: content}
+	for _, call := range calls {
+		args := map[string]any{}
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("invalid arguments for tool '%s': %s", call.Function.Name, err)
+			}
+		}
+		res.ToolCalls = append(res.ToolCalls, agent.ModelToolCall{Name: call.Function.Name, Arguments: args})
+	}
+	return res, nil
+}
+
+// Sends the request and reads the streamed response, passing text deltas to the handler.
+// Returns the generated text and the tool calls that the model made.
+func (m openaiModel) complete(ctx context.Context, request *openaiRequest, handler agent.EventHandler) (string, []openaiToolCall, error) {
+	header := http.Header{"Accept": {"text/event-stream"}}
+	if m.opts.APIKey != "" {
+		header.Set("Authorization", "Bearer "+m.opts.APIKey)
+	}
+	body, err := postJson(ctx, m.opts.HTTPClient, strings.TrimSuffix(m.opts.BaseURL, "/")+"/chat/completions", header, request)
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	var bldr strings.Builder
+	// Tool calls arrive in fragments, keyed by their index.
+	var calls []openaiToolCall
+	err = eachEventData(body, func(data []byte) error {
+		var chunk openaiChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("invalid chunk '%s': %s", data, err)
+		}
+		if chunk.Error != nil {
+			return errors.New(chunk.Error.Message)
+		}
+		if len(chunk.Choices) == 0 {
+			return nil
+		}
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			bldr.WriteString(delta.Content)
+			handler.Emit(api.NewTextDeltaEvent(delta.Content))
+		}
+		for _, fragment := range delta.ToolCalls {
+			for len(calls) <= fragment.Index {
+				calls = append(calls, openaiToolCall{Type: "function"})
+			}
+			call := &calls[fragment.Index]
+			if fragment.Id != "" {
+				call.Id = fragment.Id
+			}
+			call.Function.Name += fragment.Function.Name
+			call.Function.Arguments += fragment.Function.Arguments
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return bldr.String(), calls, nil
+}
+
+type openaiRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openaiMessage `json:"messages"`
+	Tools       []openaiTool    `json:"tools,omitempty"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	TopP        *float64        `json:"top_p,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	Stream      bool            `json:"stream"`
+}
+
+type openaiMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
+	ToolCallId string           `json:"tool_call_id,omitempty"`
+}
+
+type openaiTool struct {
+	Type     string             `json:"type"`
+	Function openaiFunctionDecl `json:"function"`
+}
+
+type openaiFunctionDecl struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type openaiToolCall struct {
+	Index    int            `json:"index,omitempty"`
+	Id       string         `json:"id,omitempty"`
+	Type     string         `json:"type,omitempty"`
+	Function openaiFunction `json:"function"`
+}
+
+type openaiFunction struct {
+	Name string `json:"name,omitempty"`
+	// The arguments encoded as a JSON object.
+	Arguments string `json:"arguments"`
+}
+
+type openaiChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openaiToolCall `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func messagesToOpenAIMessages(messages []api.Message) ([]openaiMessage, error) {
+
+	var chatMessages []openaiMessage
+	numToolCalls := 0
+
+	for _, message := range messages {
+		role := "user"
+		if message.Role == "model" {
+			role = "assistant"
+		}
+		var texts []string
+
+		for _, part := range message.Parts {
+
+			switch part := part.Part.(type) {
+			case api.TextPart:
+				texts = append(texts, part.Text)
+			case api.ResourcePart:
+				texts = append(texts, resourceIntro(part), resourceText(part))
+			case api.ToolUsePart:
+				args, err := json.Marshal(part.Input)
+				if err != nil {
+					return nil, fmt.Errorf("invalid input arguments of tool use '%v': %s", part.Input, err)
+				}
+				output, err := toolUseOutputText(part)
+				if err != nil {
+					return nil, err
+				}
+				numToolCalls += 1
+				id := fmt.Sprintf("call_%d", numToolCalls)
+
+				chatMessages = append(chatMessages, openaiMessage{
+					Role:    "assistant",
+					Content: strings.Join(texts, "\n"),
+					ToolCalls: []openaiToolCall{{
+						Id:       id,
+						Type:     "function",
+						Function: openaiFunction{Name: sanitizedToolName(part.ToolId), Arguments: string(args)},
+					}},
+				})
+				texts = nil
+
+				chatMessages = append(chatMessages, openaiMessage{
+					Role:       "tool",
+					Content:    output,
+					ToolCallId: id,
+				})
+
+			default:
+				return nil, fmt.Errorf("invalid part type '%v'", part)
+			}
+		}
+
+		if len(texts) > 0 {
+			chatMessages = append(chatMessages, openaiMessage{
+				Role:    role,
+				Content: strings.Join(texts, "\n"),
+			})
+		}
+	}
+
+	return chatMessages, nil
+}
@@ -0,0 +1,163 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// greetingClient serves a single tool that greets by name.
+type greetingClient struct {
+	requests []*agent.ServerToolRequest
+}
+
+func (c *greetingClient) ListTools(ctx context.Context) ([]*agent.ServerTool, error) {
+	return []*agent.ServerTool{{
+		ServerName: "greetings",
+		Tool: mcp.Tool{
+			Name:        "greet",
+			Description: "greets someone",
+			InputSchema: map[string]any{"type": "object"},
+		},
+	}}, nil
+}
+
+func (c *greetingClient) CallTool(ctx context.Context, toolRequest *agent.ServerToolRequest) (*api.ToolUsePart, error) {
+	c.requests = append(c.requests, toolRequest)
+	args, _ := toolRequest.Arguments.(map[string]any)
+	output := mcp.CallToolResult{StructuredContent: map[string]any{"greeting": fmt.Sprintf("Hi %s", args["name"])}}
+	part := api.NewToolUsePart(toolRequest.Arguments, output, *toolRequest.ToolId())
+	return &part, nil
+}
+
+// Serves the chat completions endpoint, answering each request with the next of the streamed responses.
+func newOpenAIServer(t *testing.T, responses ...[]string) (*httptest.Server, *[]openaiRequest) {
+	var requests []openaiRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var request openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests = append(requests, request)
+		if len(requests) > len(responses) {
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range responses[len(requests)-1] {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &requests
+}
+
+func TestOpenAIAgentCallsTools(t *testing.T) {
+	ts, requests := newOpenAIServer(t,
+		[]string{
+			`{"choices":[{"delta":{"content":"Let me "}}]}`,
+			`{"choices":[{"delta":{"content":"greet."}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"abc","type":"function","function":{"name":"greetings__greet","arguments":"{\"na"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"me\":\"Ada\"}"}}]}}]}`,
+		},
+		[]string{`{"choices":[{"delta":{"content":"Ada was greeted."}}]}`},
+	)
+	a, _ := NewOpenAIAgent(&OpenAIOpts{Model: "stand-in", BaseURL: ts.URL + "/v1/", APIKey: "key"})
+	client := &greetingClient{}
+
+	temperature := 0.0
+	var deltas []string
+	res, err := a.Stream(context.Background(), client, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Greet Ada"))},
+	}}, &agent.GenerateOptions{SystemPrompt: "Be brief.", MaxOutputTokens: 100, Temperature: &temperature, StopSequences: []string{"END"}}, func(event api.GenerationEvent) {
+		if event.Type == "text-delta" {
+			deltas = append(deltas, event.Text)
+		}
+	})
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(client.requests) != 1 || client.requests[0].ServerName != "greetings" || client.requests[0].Name != "greet" {
+		t.Fatalf("expected greet to be called once but found %v", client.requests)
+	}
+	if strings.Join(deltas, "") != "Let me greet.Ada was greeted." {
+		t.Errorf("expected the text to be streamed but found %q", deltas)
+	}
+	if res.Model != "stand-in" || len(res.Message.Parts) != 3 {
+		t.Fatalf("expected the text, tool use and answer but found %+v", res)
+	}
+	if use, ok := res.Message.Parts[1].Part.(api.ToolUsePart); !ok || use.Output.StructuredContent.(map[string]any)["greeting"] != "Hi Ada" {
+		t.Errorf("expected the tool use to hold the greeting but found %+v", res.Message.Parts[1])
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected two requests but found %d", len(*requests))
+	}
+	first := (*requests)[0]
+	if first.Model != "stand-in" || first.MaxTokens != 100 || first.Temperature == nil || *first.Temperature != 0 || len(first.Stop) != 1 || !first.Stream || first.Messages[0].Role != "system" || !strings.HasPrefix(first.Messages[0].Content, "Be brief.") {
+		t.Errorf("expected the options to be sent but found %+v", first)
+	}
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != "greetings__greet" {
+		t.Errorf("expected the tool to be declared but found %+v", first.Tools)
+	}
+	second := (*requests)[1]
+	last := second.Messages[len(second.Messages)-1]
+	call := second.Messages[len(second.Messages)-2]
+	if last.Role != "tool" || last.Content != `{"greeting":"Hi Ada"}` || last.ToolCallId != call.ToolCalls[0].Id || call.Content != "Let me greet." {
+		t.Errorf("expected the tool call and its result to be sent back but found %+v", second.Messages)
+	}
+}
+
+func TestOpenAIAgentReturnsUnknownToolNames(t *testing.T) {
+	ts, requests := newOpenAIServer(t,
+		[]string{`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"abc","type":"function","function":{"name":"lookup.weather","arguments":"{}"}}]}}]}`},
+		[]string{`{"choices":[{"delta":{"content":"There is no such tool."}}]}`},
+	)
+	a, _ := NewOpenAIAgent(&OpenAIOpts{BaseURL: ts.URL + "/v1", APIKey: "key"})
+
+	_, err := a.Act(context.Background(), &greetingClient{}, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("What is the weather?"))},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("could not generate: %s", err)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected two requests but found %d", len(*requests))
+	}
+	messages := (*requests)[1].Messages
+	call := messages[len(messages)-2]
+	if len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Name != "lookup.weather" {
+		t.Errorf("expected the unknown tool to be sent back under the name the model called but found %+v", call)
+	}
+}
+
+func TestOpenAIAgentReportsErrors(t *testing.T) {
+	ts, _ := newOpenAIServer(t)
+	a, _ := NewOpenAIAgent(&OpenAIOpts{BaseURL: ts.URL + "/v1", APIKey: "key"})
+
+	_, err := a.Act(context.Background(), &greetingClient{}, []api.Message{{
+		Role:  "user",
+		Parts: []api.UnionPart{api.ToUnion(api.NewTextPart("Hello"))},
+	}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected the status of the endpoint in the error but found %v", err)
+	}
+}
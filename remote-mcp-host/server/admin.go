@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+)
+
+type AdminOptions struct {
+	// The bearer token that every request must carry. Required.
+	Token string
+	// How long in-flight requests to a removed or restarted server are given to finish.
+	// Defaults to 30s.
+	DrainTimeout time.Duration
+}
+
+// Creates a mux for managing the MCP servers of a host at runtime.
+// It is meant to be served on a listener separate from that of NewRemoteMcpMux.
+func NewAdminMux(host *host.McpHost, opts *AdminOptions) *http.ServeMux {
+
+	if host == nil {
+		panic("The MCP Host cannot be a null pointer")
+	}
+	if opts == nil || opts.Token == "" {
+		panic("The admin API requires a token")
+	}
+
+	data := hostAndAdminOptions{host: host, opts: *opts}
+	if data.opts.DrainTimeout <= 0 {
+		data.opts.DrainTimeout = 30 * time.Second
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /servers/{name}", requireBearerToken(opts.Token, toJson(getServerDetails, data, false)))
+	mux.HandleFunc("POST /servers", requireBearerToken(opts.Token, toJson(postServer, data, true)))
+	mux.HandleFunc("DELETE /servers/{name}", requireBearerToken(opts.Token, toJson(deleteServer, data, false)))
+	mux.HandleFunc("POST /servers/{name}/restart", requireBearerToken(opts.Token, toJson(restartServer, data, false)))
+	mux.HandleFunc("PATCH /servers/{name}", requireBearerToken(opts.Token, toJson(patchServer, data, true)))
+	mux.HandleFunc("POST /servers/{name}/tools/refresh", requireBearerToken(opts.Token, toJson(refreshServerTools, data, false)))
+
+	return mux
+}
+
+type hostAndAdminOptions struct {
+	host *host.McpHost
+	opts AdminOptions
+}
+
+type serverDetails struct {
+	Config    host.ServerConfig    `json:"config"`
+	Status    api.McpServerListing `json:"status"`
+	ToolCache host.ToolCacheStats  `json:"toolCache"`
+}
+
+func (d hostAndAdminOptions) details(name string) (serverDetails, error) {
+	cfg, err := d.host.GetServerConfig(name)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	status, err := d.host.GetServerStatus(name)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	toolCache, err := d.host.GetToolCacheStats(name)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	return serverDetails{
+		Config:    redactConfig(cfg),
+		Status:    api.McpServerListing{Name: name, State: string(status.State), Error: status.Error},
+		ToolCache: toolCache,
+	}, nil
+}
+
+const redacted = "***"
+
+// Copies the config with the values of its secrets replaced, keeping the names of its headers and environment variables.
+func redactConfig(cfg host.ServerConfig) host.ServerConfig {
+	redactValues := func(values map[string]string) map[string]string {
+		if values == nil {
+			return nil
+		}
+		copied := make(map[string]string, len(values))
+		for key := range values {
+			copied[key] = redacted
+		}
+		return copied
+	}
+	cfg.Env = redactValues(cfg.Env)
+	cfg.Headers = redactValues(cfg.Headers)
+	if cfg.Auth != nil && cfg.Auth.BearerToken != "" {
+		auth := *cfg.Auth
+		auth.BearerToken = redacted
+		cfg.Auth = &auth
+	}
+	return cfg
+}
+
+// A context for closing sessions that outlives the request, but not the drain timeout.
+func (d hostAndAdminOptions) drainContext(r *http.Request) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(r.Context()), d.opts.DrainTimeout)
+}
+
+func getServerDetails(_ noBody, data hostAndAdminOptions, r *http.Request) (serverDetails, error) {
+	return data.details(r.PathValue("name"))
+}
+
+func postServer(body json.RawMessage, data hostAndAdminOptions, r *http.Request) (serverDetails, error) {
+	cfg, err := host.ParseServerConfig(body)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	if !cfg.IsEnabled() {
+		return serverDetails{}, fmt.Errorf("cannot add a disabled server")
+	}
+
+	err = data.host.AddServers(r.Context(), &host.HostConfig{Servers: []host.ServerConfig{*cfg}}, nil)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	return data.details(cfg.Name)
+}
+
+func deleteServer(_ noBody, data hostAndAdminOptions, r *http.Request) (api.McpServerListing, error) {
+	name := r.PathValue("name")
+	ctx, cancel := data.drainContext(r)
+	defer cancel()
+
+	if err := data.host.RemoveSession(ctx, name); err != nil {
+		return api.McpServerListing{}, err
+	}
+	return api.McpServerListing{Name: name, State: string(host.StateStopped)}, nil
+}
+
+func restartServer(_ noBody, data hostAndAdminOptions, r *http.Request) (serverDetails, error) {
+	name := r.PathValue("name")
+	cfg, err := data.host.GetServerConfig(name)
+	if err != nil {
+		return serverDetails{}, err
+	}
+
+	ctx, cancel := data.drainContext(r)
+	defer cancel()
+	if err := data.host.ReplaceSession(ctx, cfg, nil); err != nil {
+		return serverDetails{}, err
+	}
+	return data.details(name)
+}
+
+// Lists the tools of a server again, in case it changed them without notifying the host.
+func refreshServerTools(_ noBody, data hostAndAdminOptions, r *http.Request) (serverDetails, error) {
+	name := r.PathValue("name")
+	if err := data.host.RefreshTools(r.Context(), name); err != nil {
+		return serverDetails{}, err
+	}
+	return data.details(name)
+}
+
+// Applies a JSON merge patch (RFC 7386) to the configuration of a server and restarts it.
+// A server cannot be disabled by a patch, since the host keeps no disabled servers; it is removed instead.
+func patchServer(patch map[string]any, data hostAndAdminOptions, r *http.Request) (serverDetails, error) {
+	name := r.PathValue("name")
+	if newName, ok := patch["name"]; ok && newName != name {
+		return serverDetails{}, fmt.Errorf("a server cannot be renamed")
+	}
+
+	cfg, err := data.host.GetServerConfig(name)
+	if err != nil {
+		return serverDetails{}, err
+	}
+
+	var current map[string]any
+	if encoded, err := json.Marshal(cfg); err != nil {
+		return serverDetails{}, err
+	} else if err := json.Unmarshal(encoded, &current); err != nil {
+		return serverDetails{}, err
+	}
+	mergePatch(current, patch)
+
+	patched, err := json.Marshal(current)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	newCfg, err := host.ParseServerConfig(patched)
+	if err != nil {
+		return serverDetails{}, err
+	}
+	if !newCfg.IsEnabled() {
+		return serverDetails{}, fmt.Errorf("a server cannot be disabled; remove it with DELETE /servers/%s instead", name)
+	}
+
+	ctx, cancel := data.drainContext(r)
+	defer cancel()
+	if err := data.host.ReplaceSession(ctx, *newCfg, nil); err != nil {
+		return serverDetails{}, err
+	}
+	return data.details(name)
+}
+
+func mergePatch(target map[string]any, patch map[string]any) {
+	for key, val := range patch {
+		if val == nil {
+			delete(target, key)
+			continue
+		}
+		if patchMap, ok := val.(map[string]any); ok {
+			if targetMap, ok := target[key].(map[string]any); ok {
+				mergePatch(targetMap, patchMap)
+				continue
+			}
+			nested := make(map[string]any)
+			mergePatch(nested, patchMap)
+			val = nested
+		}
+		target[key] = val
+	}
+}
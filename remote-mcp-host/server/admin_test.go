@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func adminRequest(mux http.Handler, method string, target string, body string) *http.Response {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	r := httptest.NewRequest(method, target, reader)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w.Result()
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	host, _ := host.NewMcpHost(nil)
+	mux := NewAdminMux(&host, &AdminOptions{Token: "admin-token"})
+
+	r := httptest.NewRequest("DELETE", "/servers/greetings", nil)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Authorization", "Bearer wrong-token")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d; got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestAdminManageServer(t *testing.T) {
+	mcpHost, _ := host.NewMcpHost(nil)
+	defer mcpHost.Close()
+	mux := NewAdminMux(&mcpHost, &AdminOptions{Token: "admin-token"})
+
+	res := adminRequest(mux, "POST", "/servers", `{"name": "greetings", "command": "go", "args": ["run", "greetings.go"], "cwd": "../../test_servers/greetings"}`)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	var details serverDetails
+	json.NewDecoder(res.Body).Decode(&details)
+	if details.Status.State != string(host.StateReady) {
+		t.Errorf("expected the new server to be ready but it was %q", details.Status.State)
+	}
+
+	res = adminRequest(mux, "PATCH", "/servers/greetings", `{"tools": {"deny": ["greet"]}}`)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	cfg, _ := mcpHost.GetServerConfig("greetings")
+	if cfg.Command != "go" || cfg.Tools.Permits("greet") {
+		t.Errorf("expected the patch to be merged into the config but found %+v", cfg)
+	}
+
+	res = adminRequest(mux, "PATCH", "/servers/greetings", `{"enabled": false}`)
+	if body, _ := io.ReadAll(res.Body); res.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "DELETE") {
+		t.Errorf("expected disabling by a patch to be refused in favour of DELETE but got %v with body '%s'", res.Status, body)
+	}
+	if _, err := mcpHost.GetServerConfig("greetings"); err != nil {
+		t.Errorf("expected the server to be kept after a refused patch: %s", err)
+	}
+
+	res = adminRequest(mux, "POST", "/servers/greetings/restart", "")
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+
+	res = adminRequest(mux, "POST", "/servers/greetings/tools/refresh", "")
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	json.NewDecoder(res.Body).Decode(&details)
+	if details.ToolCache.Invalidations != 1 || details.ToolCache.Misses != 1 {
+		t.Errorf("expected the refresh to list the tools again but found %+v", details.ToolCache)
+	}
+
+	res = adminRequest(mux, "DELETE", "/servers/greetings", "")
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	if names := mcpHost.ListServerNames(); len(names) != 0 {
+		t.Errorf("expected no servers after deletion but found %v", names)
+	}
+}
+
+func TestAdminRedactsSecrets(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "search", Version: "v1.0.0"}, nil)
+	ts := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(r *http.Request) *mcp.Server { return server }, nil))
+	defer ts.Close()
+	mcpHost, _ := host.NewMcpHost(nil)
+	defer mcpHost.Close()
+	mux := NewAdminMux(&mcpHost, &AdminOptions{Token: "admin-token"})
+
+	res := adminRequest(mux, "POST", "/servers", `{"name": "greetings", "command": "go", "args": ["run", "greetings.go"],
+		"cwd": "../../test_servers/greetings", "env": {"API_KEY": "env-secret"}}`)
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	res = adminRequest(mux, "POST", "/servers", `{"name": "search", "url": "`+ts.URL+`", "headers": {"X-Api-Key": "header-secret"},
+		"auth": {"bearerToken": "token-secret"}}`)
+	if res.StatusCode != http.StatusOK {
+		got, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, got)
+	}
+
+	for _, name := range []string{"greetings", "search"} {
+		res := adminRequest(mux, "GET", "/servers/"+name, "")
+		got, _ := io.ReadAll(res.Body)
+		body = append(body, got...)
+	}
+	for _, secret := range []string{"env-secret", "header-secret", "token-secret"} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("expected %s not to be in the responses but found '%s'", secret, body)
+		}
+	}
+	if !strings.Contains(string(body), `"API_KEY":"***"`) || !strings.Contains(string(body), `"X-Api-Key":"***"`) {
+		t.Errorf("expected the names of the environment variables and headers to be kept but found '%s'", body)
+	}
+
+	cfg, _ := mcpHost.GetServerConfig("greetings")
+	if cfg.Env["API_KEY"] != "env-secret" {
+		t.Errorf("expected the host to keep the secret but found %+v", cfg.Env)
+	}
+
+	res = adminRequest(mux, "PATCH", "/servers/search", `{"headers": {"X-Trace": "on"}}`)
+	body, _ = io.ReadAll(res.Body)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	if strings.Contains(string(body), "token-secret") || strings.Contains(string(body), "header-secret") {
+		t.Errorf("expected the patched server's secrets not to be in the response but found '%s'", body)
+	}
+}
+
+func TestAdminRejectsInvalidServer(t *testing.T) {
+	mcpHost, _ := host.NewMcpHost(nil)
+	mux := NewAdminMux(&mcpHost, &AdminOptions{Token: "admin-token"})
+
+	res := adminRequest(mux, "POST", "/servers", `{"name": "math", "transport": "http", "comand": "go"}`)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status %d; got %v", http.StatusBadRequest, res.Status)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if !strings.Contains(string(body), "comand: unknown field") {
+		t.Errorf("expected the error to name the unknown field but it was '%s'", body)
+	}
+}
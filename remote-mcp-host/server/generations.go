@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// generationRegistry tracks the generations in progress, so that input they require can be answered.
+type generationRegistry struct {
+	mu          sync.Mutex
+	generations map[string]*generation
+}
+
+func newGenerationRegistry() *generationRegistry {
+	return &generationRegistry{generations: make(map[string]*generation)}
+}
+
+// Registers a generation under the id, or under a new one if the id is empty.
+// The generation's input requests are reported to the handler and abandoned once ctx is done.
+func (r *generationRegistry) start(ctx context.Context, id string, handler agent.EventHandler) (*generation, error) {
+	if id == "" {
+		id = rand.Text()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.generations[id]; ok {
+		return nil, fmt.Errorf("generation '%s' is already in progress", id)
+	}
+	g := &generation{id: id, ctx: ctx, handler: handler}
+	r.generations[id] = g
+	return g, nil
+}
+
+func (r *generationRegistry) finish(g *generation) {
+	r.mu.Lock()
+	delete(r.generations, g.id)
+	r.mu.Unlock()
+
+	g.mu.Lock()
+	g.finished = true
+	g.mu.Unlock()
+}
+
+func (r *generationRegistry) get(id string) (*generation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.generations[id]
+	if !ok {
+		return nil, fmt.Errorf("no generation '%s' is in progress", id)
+	}
+	return g, nil
+}
+
+// generation is a generation in progress. It answers elicitation requests by asking the API caller
+// and keeps the progress reported for its tool calls.
+type generation struct {
+	id      string
+	ctx     context.Context
+	handler agent.EventHandler
+
+	mu        sync.Mutex
+	lastInput int
+	pending   []*pendingInput
+	// The latest progress of each tool call, in the order the calls first reported progress.
+	progress []api.ToolProgress
+	// Set once the generation's response is written, after which no events may be emitted.
+	finished bool
+}
+
+type pendingInput struct {
+	request api.InputRequest
+	answer  chan *mcp.ElicitResult
+}
+
+// Reports the request for input and waits until it is answered through answer.
+func (g *generation) Elicit(ctx context.Context, serverName string, params *mcp.ElicitParams) (*mcp.ElicitResult, error) {
+	g.mu.Lock()
+	g.lastInput++
+	input := &pendingInput{
+		request: api.InputRequest{
+			Id:              strconv.Itoa(g.lastInput),
+			ServerName:      serverName,
+			Message:         params.Message,
+			RequestedSchema: params.RequestedSchema,
+		},
+		answer: make(chan *mcp.ElicitResult, 1),
+	}
+	g.pending = append(g.pending, input)
+	g.mu.Unlock()
+
+	defer g.take(input.request.Id)
+	g.handler.Emit(api.NewInputRequiredEvent(g.id, input.request))
+
+	select {
+	case res := <-input.answer:
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-g.ctx.Done():
+		return nil, g.ctx.Err()
+	}
+}
+
+// Records the progress of a tool call and reports it.
+func (g *generation) ReportProgress(progress api.ToolProgress) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.finished {
+		return
+	}
+	i := slices.IndexFunc(g.progress, func(p api.ToolProgress) bool { return p.CallId == progress.CallId })
+	if i < 0 {
+		g.progress = append(g.progress, progress)
+	} else {
+		g.progress[i] = progress
+	}
+	g.handler.Emit(api.NewToolProgressEvent(progress))
+}
+
+// Removes and returns the pending input request with the id, if there is one.
+func (g *generation) take(id string) *pendingInput {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	i := slices.IndexFunc(g.pending, func(input *pendingInput) bool { return input.request.Id == id })
+	if i < 0 {
+		return nil
+	}
+	input := g.pending[i]
+	g.pending = slices.Delete(g.pending, i, i+1)
+	return input
+}
+
+func (g *generation) answer(res api.InputResponse) error {
+	switch res.Action {
+	case "accept":
+	case "decline", "cancel":
+		if res.Content != nil {
+			return fmt.Errorf("content is only given with the accept action")
+		}
+	default:
+		return fmt.Errorf("invalid action '%s': expected accept, decline or cancel", res.Action)
+	}
+
+	input := g.take(res.InputRequestId)
+	if input == nil {
+		return fmt.Errorf("generation '%s' has no pending input request '%s'", g.id, res.InputRequestId)
+	}
+	input.answer <- &mcp.ElicitResult{Action: res.Action, Content: res.Content}
+	return nil
+}
+
+func (g *generation) status() api.GenerationStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	status := api.GenerationStatus{
+		Id:            g.id,
+		State:         "running",
+		InputRequests: []api.InputRequest{},
+		Progress:      slices.Clone(g.progress),
+	}
+	for _, input := range g.pending {
+		status.InputRequests = append(status.InputRequests, input.request)
+	}
+	if len(g.pending) > 0 {
+		status.State = "input-required"
+	}
+	if status.Progress == nil {
+		status.Progress = []api.ToolProgress{}
+	}
+	return status
+}
+
+func getGeneration(_ noBody, hostAndAgent hostAndAgent, r *http.Request) (api.GenerationStatus, error) {
+	g, err := hostAndAgent.generations.get(r.PathValue("id"))
+	if err != nil {
+		return api.GenerationStatus{}, err
+	}
+	return g.status(), nil
+}
+
+func postGenerationInput(req api.InputResponse, hostAndAgent hostAndAgent, r *http.Request) (api.GenerationStatus, error) {
+	g, err := hostAndAgent.generations.get(r.PathValue("id"))
+	if err != nil {
+		return api.GenerationStatus{}, err
+	}
+	if err := g.answer(req); err != nil {
+		return api.GenerationStatus{}, err
+	}
+	return g.status(), nil
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshua-zingale/remote-mcp-host/internal/testutil"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolAgent calls a tool before echoing the user, responding with the tool's use and the echo.
+type toolAgent struct {
+	testutil.EchoAgent
+	request agent.ServerToolRequest
+}
+
+func (a toolAgent) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+	return a.Stream(ctx, client, messages, opts, nil)
+}
+
+func (a toolAgent) Stream(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions, handler agent.EventHandler) (*agent.GenerateResult, error) {
+	client = agent.WithToolEvents(client, handler)
+	toolUse, err := client.CallTool(ctx, &a.request)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.EchoAgent.Stream(ctx, client, messages, opts, handler)
+	if err != nil {
+		return nil, err
+	}
+	res.Message.Parts = append([]api.UnionPart{api.ToUnion(*toolUse)}, res.Message.Parts...)
+	return res, nil
+}
+
+var orderingAgent = toolAgent{request: agent.ServerToolRequest{
+	ServerName:     "elicitation",
+	CallToolParams: mcp.CallToolParams{Name: "order", Arguments: map[string]any{}},
+}}
+
+var countingAgent = toolAgent{request: agent.ServerToolRequest{
+	ServerName:     "progress",
+	CallToolParams: mcp.CallToolParams{Name: "count", Arguments: map[string]any{"to": 3, "wait": 0.5}},
+}}
+
+// Serves generations by the agent with a host connected to the test server of the given name.
+func newGenerationServer(t *testing.T, serverName string, agent agent.Agent) *httptest.Server {
+	host, _ := host.NewMcpHost(nil)
+	t.Cleanup(func() { host.Close() })
+	config := fmt.Sprintf("![../../test_servers/%[1]s][%[1]s] go run %[1]s.go", serverName)
+	if err := host.AddSessionsFromConfig(context.Background(), strings.NewReader(config), nil); err != nil {
+		t.Fatalf("could not add sessions: %s", err)
+	}
+	ts := httptest.NewServer(NewRemoteMcpMux(&host, agent))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func postJson(t *testing.T, url string, body any, accept string) *http.Response {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", url, strings.NewReader(string(data)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", accept)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("could not post to %s: %s", url, err)
+	}
+	return res
+}
+
+func getJson(t *testing.T, url string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Accept", "application/json")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("could not get %s: %s", url, err)
+	}
+	return res
+}
+
+func orderOf(message *api.Message) string {
+	if message == nil || len(message.Parts) == 0 {
+		return ""
+	}
+	toolUse, ok := message.Parts[0].Part.(api.ToolUsePart)
+	if !ok {
+		return ""
+	}
+	output, _ := toolUse.Output.StructuredContent.(map[string]any)
+	order, _ := output["order"].(string)
+	return order
+}
+
+func TestElicitationStream(t *testing.T) {
+	ts := newGenerationServer(t, "elicitation", orderingAgent)
+
+	res := postJson(t, ts.URL+"/generations", api.GenerationRequest{
+		Messages: []api.Message{{Role: "user", Parts: []api.UnionPart{{Part: api.NewTextPart("coffee, please")}}}},
+	}, "text/event-stream")
+	defer res.Body.Close()
+
+	var final *api.Message
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event api.GenerationEvent
+		json.Unmarshal([]byte(data), &event)
+
+		switch event.Type {
+		case "input-required":
+			if event.InputRequest.ServerName != "elicitation" || event.InputRequest.Message != "What size of coffee would you like?" {
+				t.Errorf("unexpected input request %+v", event.InputRequest)
+			}
+			answer := postJson(t, ts.URL+"/generations/"+event.GenerationId+"/input", api.InputResponse{
+				InputRequestId: event.InputRequest.Id,
+				Action:         "accept",
+				Content:        map[string]any{"size": "large"},
+			}, "application/json")
+			if answer.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(answer.Body)
+				t.Fatalf("expected status OK when answering; got %v with body '%s'", answer.Status, body)
+			}
+		case "final-message":
+			final = event.Message
+		case "error":
+			t.Fatalf("generation failed: %s", event.Error)
+		}
+	}
+
+	if order := orderOf(final); order != "a large coffee" {
+		t.Errorf("expected a large coffee to be ordered but found '%s'", order)
+	}
+}
+
+func TestElicitationPolling(t *testing.T) {
+	ts := newGenerationServer(t, "elicitation", orderingAgent)
+
+	result := make(chan *http.Response)
+	go func() {
+		result <- postJson(t, ts.URL+"/generations", api.GenerationRequest{
+			GenerationId: "coffee",
+			Messages:     []api.Message{{Role: "user", Parts: []api.UnionPart{{Part: api.NewTextPart("coffee, please")}}}},
+		}, "application/json")
+	}()
+
+	var status api.GenerationStatus
+	deadline := time.Now().Add(10 * time.Second)
+	for status.State != "input-required" {
+		if time.Now().After(deadline) {
+			t.Fatalf("the generation never required input; last status %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		res := getJson(t, ts.URL+"/generations/coffee")
+		json.NewDecoder(res.Body).Decode(&status)
+		res.Body.Close()
+	}
+
+	answer := postJson(t, ts.URL+"/generations/coffee/input", api.InputResponse{
+		InputRequestId: status.InputRequests[0].Id,
+		Action:         "decline",
+	}, "application/json")
+	if answer.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK when answering; got %v", answer.Status)
+	}
+
+	res := <-result
+	var genRes api.GenerationResponse
+	json.NewDecoder(res.Body).Decode(&genRes)
+	if order := orderOf(&genRes.Message); order != "nothing" {
+		t.Errorf("expected nothing to be ordered but found '%s'", order)
+	}
+
+	if res := getJson(t, ts.URL+"/generations/coffee"); res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a finished generation to be forgotten; got %v", res.Status)
+	}
+}
+
+func TestProgressStream(t *testing.T) {
+	ts := newGenerationServer(t, "progress", countingAgent)
+
+	res := postJson(t, ts.URL+"/generations", api.GenerationRequest{
+		Messages: []api.Message{{Role: "user", Parts: []api.UnionPart{{Part: api.NewTextPart("count, please")}}}},
+	}, "text/event-stream")
+	defer res.Body.Close()
+
+	var progress []api.ToolProgress
+	for _, event := range readEvents(t, res.Body) {
+		switch event.Type {
+		case "tool-progress":
+			progress = append(progress, *event.Progress)
+		case "tool-call-finished":
+			if len(progress) != 3 {
+				t.Fatalf("expected 3 progress events before the call finished but found %v", progress)
+			}
+		}
+	}
+
+	last := progress[len(progress)-1]
+	if last.Progress != 3 || last.Total != 3 || last.Message != "counted 3" || last.ToolId.Name != "count" || last.CallId == "" {
+		t.Errorf("unexpected progress %+v", last)
+	}
+}
+
+func TestProgressStatus(t *testing.T) {
+	ts := newGenerationServer(t, "progress", countingAgent)
+
+	result := make(chan *http.Response)
+	go func() {
+		result <- postJson(t, ts.URL+"/generations", api.GenerationRequest{
+			GenerationId: "counting",
+			Messages:     []api.Message{{Role: "user", Parts: []api.UnionPart{{Part: api.NewTextPart("count, please")}}}},
+		}, "application/json")
+	}()
+
+	var status api.GenerationStatus
+	deadline := time.Now().Add(10 * time.Second)
+	for len(status.Progress) == 0 || status.Progress[0].Progress != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the generation never reported its progress; last status %+v", status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		res := getJson(t, ts.URL+"/generations/counting")
+		json.NewDecoder(res.Body).Decode(&status)
+		res.Body.Close()
+	}
+	if len(status.Progress) != 1 || status.Progress[0].Total != 3 {
+		t.Errorf("expected the latest progress of the one tool call but found %+v", status.Progress)
+	}
+
+	if res := <-result; res.StatusCode != http.StatusOK {
+		t.Errorf("expected the generation to succeed; got %v", res.Status)
+	}
+}
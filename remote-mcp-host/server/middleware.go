@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -52,6 +53,20 @@ func toJson[Req any, Res any, Dat any](handler func(Req, Dat, *http.Request) (Re
 }
 
 type hostAndAgent struct {
-	host  *host.McpHost
-	agent agent.Agent
+	host        *host.McpHost
+	agent       agent.Agent
+	generations *generationRegistry
+}
+
+// Rejects requests that do not carry the given bearer token in their Authorization header.
+func requireBearerToken(token string, handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	expected := []byte("Bearer " + token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
 }
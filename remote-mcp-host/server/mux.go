@@ -1,7 +1,12 @@
 package server
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
@@ -19,15 +24,50 @@ func NewRemoteMcpMux(host *host.McpHost, agent agent.Agent) *http.ServeMux {
 
 	mux.HandleFunc("GET /servers", toJson(getServers, host, false))
 	mux.HandleFunc("GET /servers/{name}/tools", toJson(getServerTools, host, false))
-	mux.HandleFunc("POST /generations", toJson(postGenerations, hostAndAgent{
-		host:  host,
-		agent: agent,
-	}, true))
+	mux.HandleFunc("GET /servers/{name}/resources", toJson(getServerResources, host, false))
+	mux.HandleFunc("GET /servers/{name}/resources/templates", toJson(getServerResourceTemplates, host, false))
+	mux.HandleFunc("GET /servers/{name}/resources/read", toJson(readServerResource, host, false))
+	mux.HandleFunc("GET /servers/{name}/prompts", toJson(getServerPrompts, host, false))
+	mux.HandleFunc("GET /servers/{name}/logs", toJson(getServerLogs, host, false))
+	mux.HandleFunc("POST /servers/{name}/prompts/{prompt}", toJson(postServerPrompt, host, true))
+	generator := hostAndAgent{
+		host:        host,
+		agent:       agent,
+		generations: newGenerationRegistry(),
+	}
+	mux.HandleFunc("POST /generations", withEventStream(
+		toEventStream(streamGenerations, generator, true),
+		toJson(postGenerations, generator, true)))
+	mux.HandleFunc("GET /generations/{id}", toJson(getGeneration, generator, false))
+	mux.HandleFunc("POST /generations/{id}/input", toJson(postGenerationInput, generator, true))
 
 	return mux
 }
 
 func postGenerations(req api.GenerationRequest, hostAndAgent hostAndAgent, r *http.Request) (api.GenerationResponse, error) {
+	res, err := generate(req, hostAndAgent, r, nil)
+	if err != nil {
+		return api.GenerationResponse{}, err
+	}
+	return api.GenerationResponse{Message: *res.Message}, err
+}
+
+// Streams the generation as events, ending with a final-message event.
+func streamGenerations(req api.GenerationRequest, hostAndAgent hostAndAgent, r *http.Request, handler agent.EventHandler) error {
+	res, err := generate(req, hostAndAgent, r, handler)
+	if err != nil {
+		return err
+	}
+	handler.Emit(api.NewFinalMessageEvent(*res.Message))
+	return nil
+}
+
+func generate(req api.GenerationRequest, hostAndAgent hostAndAgent, r *http.Request, handler agent.EventHandler) (*agent.GenerateResult, error) {
+
+	opts, err := generateOptions(req.GenerationOptions)
+	if err != nil {
+		return nil, err
+	}
 
 	var toolConfigs []*api.ToolConfig
 
@@ -35,24 +75,129 @@ func postGenerations(req api.GenerationRequest, hostAndAgent hostAndAgent, r *ht
 		toolConfigs = append(toolConfigs, &conf)
 	}
 
+	gen, err := hostAndAgent.generations.start(r.Context(), req.GenerationId, handler)
+	if err != nil {
+		return nil, err
+	}
+	defer hostAndAgent.generations.finish(gen)
+
 	client, err := hostAndAgent.host.GetClient(r.Context(), &host.ClientOptions{
 		ToolConfigs: toolConfigs,
+		Elicitor:    gen,
+		Progress:    gen,
 	})
 	if err != nil {
-		return api.GenerationResponse{}, err
+		return nil, err
 	}
 
-	res, err := hostAndAgent.agent.Act(r.Context(), client, req.Messages, nil)
+	// The roots are announced to the server's one session, so concurrent generations see them too.
+	for _, ref := range req.Roots {
+		release, err := hostAndAgent.host.AddRoots(ref.ServerName, &mcp.Root{URI: ref.URI, Name: ref.Name})
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+
+	messages := req.Messages
+	if req.Prompt != nil {
+		promptMessages, _, err := hostAndAgent.host.GetPrompt(r.Context(), req.Prompt.ServerName, req.Prompt.Name, req.Prompt.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		messages = slices.Concat(promptMessages, messages)
+	}
+
+	messages, err = attachResources(r.Context(), hostAndAgent.host, messages, req.Resources)
 	if err != nil {
-		return api.GenerationResponse{}, err
+		return nil, err
 	}
-	return api.GenerationResponse{Message: *res.Message}, err
+
+	if handler == nil {
+		return hostAndAgent.agent.Act(r.Context(), client, messages, opts)
+	}
+	return hostAndAgent.agent.Stream(r.Context(), client, messages, opts, handler)
+}
+
+const (
+	maxStopSequences = 16
+	maxToolRounds    = 10
+)
+
+// Validates the options of a generation request.
+func generateOptions(opts api.GenerationOptions) (*agent.GenerateOptions, error) {
+	if opts.Temperature != nil && (*opts.Temperature < 0 || *opts.Temperature > 2) {
+		return nil, fmt.Errorf("invalid temperature %v: expected a value between 0 and 2", *opts.Temperature)
+	}
+	if opts.TopP != nil && (*opts.TopP < 0 || *opts.TopP > 1) {
+		return nil, fmt.Errorf("invalid topP %v: expected a value between 0 and 1", *opts.TopP)
+	}
+	if opts.MaxOutputTokens < 0 {
+		return nil, fmt.Errorf("invalid maxOutputTokens %d: expected a positive number", opts.MaxOutputTokens)
+	}
+	if len(opts.StopSequences) > maxStopSequences {
+		return nil, fmt.Errorf("too many stopSequences: expected at most %d", maxStopSequences)
+	}
+	if slices.Contains(opts.StopSequences, "") {
+		return nil, fmt.Errorf("invalid stopSequences: a sequence is empty")
+	}
+	if opts.MaxToolRounds < 0 || opts.MaxToolRounds > maxToolRounds {
+		return nil, fmt.Errorf("invalid maxToolRounds %d: expected a number between 0 and %d", opts.MaxToolRounds, maxToolRounds)
+	}
+	if opts.Seed != nil && (*opts.Seed < math.MinInt32 || *opts.Seed > math.MaxInt32) {
+		return nil, fmt.Errorf("invalid seed %d: expected a 32-bit integer", *opts.Seed)
+	}
+	return &agent.GenerateOptions{
+		SystemPrompt:    opts.SystemPrompt,
+		MaxOutputTokens: opts.MaxOutputTokens,
+		Temperature:     opts.Temperature,
+		TopP:            opts.TopP,
+		StopSequences:   opts.StopSequences,
+		MaxToolRounds:   opts.MaxToolRounds,
+		Seed:            opts.Seed,
+	}, nil
+}
+
+// Reads the resources and adds their contents to the start of the last message,
+// or in a new user message if the last message is not from the user.
+// The given messages are not modified.
+func attachResources(ctx context.Context, host *host.McpHost, messages []api.Message, resources []api.ResourceRef) ([]api.Message, error) {
+	if len(resources) == 0 {
+		return messages, nil
+	}
+
+	var parts []api.UnionPart
+	for _, ref := range resources {
+		contents, err := host.ReadResource(ctx, ref.ServerName, ref.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, content := range contents {
+			parts = append(parts, api.ToUnion(api.NewResourcePart(ref.ServerName, content)))
+		}
+	}
+
+	messages = slices.Clone(messages)
+	if len(messages) == 0 || messages[len(messages)-1].Role != "user" {
+		return append(messages, api.Message{Role: "user", Parts: parts}), nil
+	}
+	last := &messages[len(messages)-1]
+	last.Parts = append(parts, last.Parts...)
+	return messages, nil
 }
 
 func getServers(_ noBody, host *host.McpHost, _ *http.Request) (api.McpServerList, error) {
 	var list []api.McpServerListing
 	for _, name := range host.ListServerNames() {
-		list = append(list, api.McpServerListing{Name: name})
+		status, err := host.GetServerStatus(name)
+		if err != nil {
+			continue
+		}
+		list = append(list, api.McpServerListing{
+			Name:  name,
+			State: string(status.State),
+			Error: status.Error,
+		})
 	}
 	return api.McpServerList{
 		Servers: list,
@@ -60,33 +205,83 @@ func getServers(_ noBody, host *host.McpHost, _ *http.Request) (api.McpServerLis
 }
 
 func getServerTools(_ interface{}, host *host.McpHost, r *http.Request) (api.ToolList, error) {
-	name := r.PathValue("name")
-	session, err := host.GetSession(r.Context(), name)
+	tools, err := host.ListToolsOnServer(r.Context(), r.PathValue("name"))
 	if err != nil {
 		return api.ToolList{}, err
 	}
-	if session.InitializeResult().Capabilities.Tools == nil {
-		return api.ToolList{}, nil
-	}
 
-	var tools []mcp.Tool
+	return api.ToolList{
+		Tools: tools,
+	}, nil
+}
 
-	var cursor string = ""
-	for {
-		res, err := session.ListTools(r.Context(), &mcp.ListToolsParams{Cursor: cursor})
-		if err != nil {
-			return api.ToolList{}, err
-		}
-		for _, tool := range res.Tools {
-			tools = append(tools, *tool)
-		}
-		cursor = res.NextCursor
-		if cursor == "" {
-			break
+// Gets the server's most recent log entries, or only the last ones if a limit is given.
+func getServerLogs(_ noBody, host *host.McpHost, r *http.Request) (api.ServerLogList, error) {
+	logs, err := host.GetServerLogs(r.PathValue("name"))
+	if err != nil {
+		return api.ServerLogList{}, err
+	}
+	if query := r.URL.Query().Get("limit"); query != "" {
+		limit, err := strconv.Atoi(query)
+		if err != nil || limit < 0 {
+			return api.ServerLogList{}, fmt.Errorf("invalid limit '%s'", query)
 		}
+		logs = logs[max(len(logs)-limit, 0):]
 	}
+	return api.ServerLogList{Logs: logs}, nil
+}
 
-	return api.ToolList{
-		Tools: tools,
+func getServerResources(_ noBody, host *host.McpHost, r *http.Request) (api.ResourceList, error) {
+	resources, err := host.ListResourcesOnServer(r.Context(), r.PathValue("name"))
+	if err != nil {
+		return api.ResourceList{}, err
+	}
+	return api.ResourceList{
+		Resources: resources,
+	}, nil
+}
+
+func getServerResourceTemplates(_ noBody, host *host.McpHost, r *http.Request) (api.ResourceTemplateList, error) {
+	templates, err := host.ListResourceTemplatesOnServer(r.Context(), r.PathValue("name"))
+	if err != nil {
+		return api.ResourceTemplateList{}, err
+	}
+	return api.ResourceTemplateList{
+		ResourceTemplates: templates,
+	}, nil
+}
+
+func readServerResource(_ noBody, host *host.McpHost, r *http.Request) (api.ResourceContentsList, error) {
+	uri := r.URL.Query().Get("uri")
+	if uri == "" {
+		return api.ResourceContentsList{}, fmt.Errorf("the uri query parameter is required")
+	}
+	contents, err := host.ReadResource(r.Context(), r.PathValue("name"), uri)
+	if err != nil {
+		return api.ResourceContentsList{}, err
+	}
+	return api.ResourceContentsList{
+		Contents: contents,
+	}, nil
+}
+
+func getServerPrompts(_ noBody, host *host.McpHost, r *http.Request) (api.PromptList, error) {
+	prompts, err := host.ListPromptsOnServer(r.Context(), r.PathValue("name"))
+	if err != nil {
+		return api.PromptList{}, err
+	}
+	return api.PromptList{
+		Prompts: prompts,
+	}, nil
+}
+
+func postServerPrompt(req api.PromptRequest, host *host.McpHost, r *http.Request) (api.PromptResponse, error) {
+	messages, description, err := host.GetPrompt(r.Context(), r.PathValue("name"), r.PathValue("prompt"), req.Arguments)
+	if err != nil {
+		return api.PromptResponse{}, err
+	}
+	return api.PromptResponse{
+		Description: description,
+		Messages:    messages,
 	}, nil
 }
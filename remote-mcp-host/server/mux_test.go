@@ -1,17 +1,21 @@
 package server
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/joshua-zingale/remote-mcp-host/internal/testutil"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
 	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/host"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func TestServerListing(t *testing.T) {
@@ -88,3 +92,302 @@ func TestServerGenerate(t *testing.T) {
 		t.Fatalf("Expected the response have a text part containing \"hello, world\" as its first part, but it did not. Instead found %v", genRes.Message)
 	}
 }
+
+// optionsAgent echoes the user and remembers the options it was given.
+type optionsAgent struct {
+	testutil.EchoAgent
+	opts **agent.GenerateOptions
+}
+
+func (a optionsAgent) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+	*a.opts = opts
+	return a.EchoAgent.Act(ctx, client, messages, opts)
+}
+
+func TestServerGenerateOptions(t *testing.T) {
+	host, _ := host.NewMcpHost(nil)
+	defer host.Close()
+	var opts *agent.GenerateOptions
+	mux := NewRemoteMcpMux(&host, optionsAgent{opts: &opts})
+
+	post := func(body string) *http.Response {
+		r := httptest.NewRequest("POST", "/generations", strings.NewReader(body))
+		r.Header.Set("Accept", "application/json")
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w.Result()
+	}
+	messages := `"messages": [{"role": "user", "parts": [{"type": "text", "text": "hi"}]}]`
+
+	res := post(`{` + messages + `, "systemPrompt": "Be brief.", "temperature": 0, "topP": 0.9, "maxOutputTokens": 64,
+		"stopSequences": ["END"], "maxToolRounds": 2, "seed": 7}`)
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	if opts == nil || opts.SystemPrompt != "Be brief." || opts.Temperature == nil || *opts.Temperature != 0 || *opts.TopP != 0.9 ||
+		opts.MaxOutputTokens != 64 || !slices.Equal(opts.StopSequences, []string{"END"}) || opts.MaxToolRounds != 2 || *opts.Seed != 7 {
+		t.Errorf("expected the options of the request to be given to the agent but found %+v", opts)
+	}
+
+	for _, invalid := range []string{
+		`"temperature": 2.5`,
+		`"topP": -0.1`,
+		`"maxOutputTokens": -1`,
+		`"stopSequences": [""]`,
+		`"maxToolRounds": 11`,
+		`"seed": 4294967296`,
+	} {
+		if res := post(`{` + messages + `, ` + invalid + `}`); res.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected %s to be rejected but got %v", invalid, res.Status)
+		}
+	}
+	body, _ := io.ReadAll(post(`{` + messages + `, "maxToolRounds": -1}`).Body)
+	if !strings.Contains(string(body), "between 0 and 10") {
+		t.Errorf("expected the error to give the accepted range but found '%s'", body)
+	}
+}
+
+// greetingAgent greets the user with the greetings server before echoing them.
+type greetingAgent struct {
+	testutil.EchoAgent
+}
+
+func (a greetingAgent) Stream(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions, handler agent.EventHandler) (*agent.GenerateResult, error) {
+	client = agent.WithToolEvents(client, handler)
+	if _, err := client.CallTool(ctx, &agent.ServerToolRequest{
+		ServerName:     "greetings",
+		CallToolParams: mcp.CallToolParams{Name: "greet", Arguments: map[string]any{"name": "Ada"}},
+	}); err != nil {
+		return nil, err
+	}
+	return a.EchoAgent.Stream(ctx, client, messages, opts, handler)
+}
+
+func readEvents(t *testing.T, body io.Reader) []api.GenerationEvent {
+	var events []api.GenerationEvent
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var event api.GenerationEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatalf("could not decode event '%s': %s", data, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestServerGenerateStream(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := host.NewMcpHost(nil)
+	defer host.Close()
+	host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/greetings][greetings] go run greetings.go"), nil)
+
+	mux := NewRemoteMcpMux(&host, greetingAgent{})
+
+	req, _ := json.Marshal(api.GenerationRequest{
+		Messages: []api.Message{{
+			Role:  "user",
+			Parts: []api.UnionPart{{Part: api.NewTextPart("hello, world")}},
+		}},
+	})
+
+	r := httptest.NewRequest("POST", "/generations", strings.NewReader(string(req)))
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	res := w.Result()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("expected Content-Type text/event-stream; got '%s'", ct)
+	}
+
+	events := readEvents(t, res.Body)
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	expected := []string{"tool-call-started", "tool-call-finished", "text-delta", "final-message"}
+	if strings.Join(types, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected events %v but found %v", expected, types)
+	}
+
+	if toolUse := events[1].ToolUse; toolUse == nil || toolUse.Error != "" || toolUse.ToolId.Name != "greet" {
+		t.Errorf("expected a successful call to 'greet' but found %+v", events[1].ToolUse)
+	}
+	if events[2].Text != "hello, world" {
+		t.Errorf("expected the text delta \"hello, world\" but found \"%s\"", events[2].Text)
+	}
+	if message := events[3].Message; message == nil || len(message.Parts) != 1 {
+		t.Errorf("expected a final message with one part but found %+v", events[3].Message)
+	}
+}
+
+func TestServerGenerateStreamError(t *testing.T) {
+	host, _ := host.NewMcpHost(nil)
+	mux := NewRemoteMcpMux(&host, greetingAgent{})
+
+	req, _ := json.Marshal(api.GenerationRequest{})
+	r := httptest.NewRequest("POST", "/generations", strings.NewReader(string(req)))
+	r.Header.Set("Accept", "text/event-stream")
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	res := w.Result()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status OK; got %v", res.Status)
+	}
+	events := readEvents(t, res.Body)
+	if len(events) != 3 || events[2].Type != "error" {
+		t.Fatalf("expected the stream to end with an error event but found %+v", events)
+	}
+}
+
+// recordingAgent echoes the user and remembers the messages it was given.
+type recordingAgent struct {
+	testutil.EchoAgent
+	messages *[]api.Message
+}
+
+func (a recordingAgent) Act(ctx context.Context, client agent.McpClient, messages []api.Message, opts *agent.GenerateOptions) (*agent.GenerateResult, error) {
+	*a.messages = messages
+	return a.EchoAgent.Act(ctx, client, messages, opts)
+}
+
+func TestServerResources(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := host.NewMcpHost(nil)
+	defer host.Close()
+	host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/resources][resources] go run resources.go"), nil)
+
+	var messages []api.Message
+	mux := NewRemoteMcpMux(&host, recordingAgent{messages: &messages})
+
+	get := func(target string, v any) {
+		r := httptest.NewRequest("GET", target, nil)
+		r.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		res := w.Result()
+		if res.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(res.Body)
+			t.Fatalf("expected status OK for %s; got %v with body '%s'", target, res.Status, body)
+		}
+		json.NewDecoder(res.Body).Decode(v)
+	}
+
+	var resources api.ResourceList
+	get("/servers/resources/resources", &resources)
+	if len(resources.Resources) != 1 || resources.Resources[0].Name != "motto" {
+		t.Errorf("expected the motto resource but found %v", resources.Resources)
+	}
+
+	var templates api.ResourceTemplateList
+	get("/servers/resources/resources/templates", &templates)
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].Name != "greeting" {
+		t.Errorf("expected the greeting template but found %v", templates.ResourceTemplates)
+	}
+
+	var contents api.ResourceContentsList
+	get("/servers/resources/resources/read?uri=greeting://Ada", &contents)
+	if len(contents.Contents) != 1 || contents.Contents[0].Text != "Salutations, Ada." {
+		t.Errorf("expected a greeting for Ada but found %v", contents.Contents)
+	}
+
+	req, _ := json.Marshal(api.GenerationRequest{
+		Messages: []api.Message{{
+			Role:  "user",
+			Parts: []api.UnionPart{{Part: api.NewTextPart("what is the motto?")}},
+		}},
+		Resources: []api.ResourceRef{{ServerName: "resources", URI: "file:///motto.txt"}},
+	})
+	r := httptest.NewRequest("POST", "/generations", strings.NewReader(string(req)))
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if res := w.Result(); res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+
+	if len(messages) != 1 || len(messages[0].Parts) != 2 {
+		t.Fatalf("expected the resource to be added to the user's message but found %v", messages)
+	}
+	if part, ok := messages[0].Parts[0].Part.(api.ResourcePart); !ok || part.Text != "Salutations are in order." || part.ServerName != "resources" {
+		t.Errorf("expected the motto as the first part but found %v", messages[0].Parts[0])
+	}
+}
+
+func TestServerPrompts(t *testing.T) {
+	ctx := context.Background()
+
+	host, _ := host.NewMcpHost(nil)
+	defer host.Close()
+	host.AddSessionsFromConfig(ctx, strings.NewReader("![../../test_servers/prompts][prompts] go run prompts.go"), nil)
+
+	var messages []api.Message
+	mux := NewRemoteMcpMux(&host, recordingAgent{messages: &messages})
+
+	r := httptest.NewRequest("GET", "/servers/prompts/prompts", nil)
+	r.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	var prompts api.PromptList
+	json.NewDecoder(w.Result().Body).Decode(&prompts)
+	if len(prompts.Prompts) != 1 || prompts.Prompts[0].Name != "greet" {
+		t.Errorf("expected the greet prompt but found %v", prompts.Prompts)
+	}
+
+	r = httptest.NewRequest("POST", "/servers/prompts/prompts/greet", strings.NewReader(`{"arguments": {"name": "Ada"}}`))
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	res := w.Result()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	var prompt api.PromptResponse
+	json.NewDecoder(res.Body).Decode(&prompt)
+	if prompt.Description != "Greets Ada" || len(prompt.Messages) != 3 {
+		t.Errorf("expected the greeting prompt for Ada but found %+v", prompt)
+	}
+
+	req, _ := json.Marshal(api.GenerationRequest{
+		Prompt: &api.PromptRef{ServerName: "prompts", Name: "greet", Arguments: map[string]string{"name": "Ada"}},
+		Messages: []api.Message{{
+			Role:  "user",
+			Parts: []api.UnionPart{{Part: api.NewTextPart("and now Bob")}},
+		}},
+	})
+	r = httptest.NewRequest("POST", "/generations", strings.NewReader(string(req)))
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if res := w.Result(); res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected status OK; got %v with body '%s'", res.Status, body)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected the prompt's 3 messages to precede the request's but found %v", messages)
+	}
+	if part, ok := messages[3].Parts[0].Part.(api.TextPart); !ok || part.Text != "and now Bob" {
+		t.Errorf("expected the request's message last but found %v", messages[3])
+	}
+}
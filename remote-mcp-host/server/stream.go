@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/agent"
+	"github.com/joshua-zingale/remote-mcp-host/remote-mcp-host/api"
+)
+
+// Serves requests that accept text/event-stream with the stream handler and all others with handler.
+func withEventStream(stream func(http.ResponseWriter, *http.Request), handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			stream(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// Like toJson, but the handler responds with a stream of Server-Sent Events.
+// If the handler fails before emitting an event, the error is sent as a plain 400 response;
+// afterwards, it is sent as an error event.
+func toEventStream[Req any, Dat any](handler func(Req, Dat, *http.Request, agent.EventHandler) error, data Dat, checkContentType bool) func(http.ResponseWriter, *http.Request) {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); checkContentType && ct != "application/json" {
+			http.Error(w, "Unsupported media type: Expected Content-Type: application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var requestObject Req
+		if err := json.NewDecoder(r.Body).Decode(&requestObject); err != nil {
+			log.Printf("Could not decode data: %s", err.Error())
+			http.Error(w, "Could not parse request body.", 400)
+			return
+		}
+
+		events := &eventWriter{w: w}
+		err := handler(requestObject, data, r, events.write)
+		if err == nil {
+			return
+		}
+		if !events.started() {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		events.write(api.NewErrorEvent(err.Error()))
+	}
+}
+
+// eventWriter writes events to the response as they are emitted,
+// sending the headers with the first one.
+type eventWriter struct {
+	w       http.ResponseWriter
+	mu      sync.Mutex
+	written bool
+}
+
+func (e *eventWriter) write(event api.GenerationEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Invalid event to be marshaled: %s", err.Error())
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.written {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.written = true
+	}
+	if _, err := e.w.Write([]byte("event: " + event.Type + "\ndata: " + string(data) + "\n\n")); err != nil {
+		return
+	}
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (e *eventWriter) started() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.written
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Input struct{}
+
+type Output struct {
+	Order string `json:"order" jsonschema:"the coffee that was ordered"`
+}
+
+func OrderCoffee(ctx context.Context, req *mcp.CallToolRequest, input Input) (
+	*mcp.CallToolResult,
+	Output,
+	error,
+) {
+	res, err := req.Session.Elicit(ctx, &mcp.ElicitParams{
+		Message: "What size of coffee would you like?",
+		RequestedSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"size": map[string]any{"type": "string", "enum": []string{"small", "large"}},
+			},
+			"required": []string{"size"},
+		},
+	})
+	if err != nil {
+		return nil, Output{}, err
+	}
+	if res.Action != "accept" {
+		return nil, Output{Order: "nothing"}, nil
+	}
+	return nil, Output{Order: "a " + res.Content["size"].(string) + " coffee"}, nil
+}
+
+func main() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "elicitation", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "order", Description: "orders a coffee"}, OrderCoffee)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
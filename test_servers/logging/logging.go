@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Input struct {
+	Message string `json:"message" jsonschema:"the message to log"`
+	Level   string `json:"level" jsonschema:"the MCP logging level of the message"`
+}
+
+type Output struct{}
+
+func Log(ctx context.Context, req *mcp.CallToolRequest, input Input) (
+	*mcp.CallToolResult,
+	Output,
+	error,
+) {
+	err := req.Session.Log(ctx, &mcp.LoggingMessageParams{
+		Level:  mcp.LoggingLevel(input.Level),
+		Logger: "logging",
+		Data:   input.Message,
+	})
+	return nil, Output{}, err
+}
+
+func main() {
+	fmt.Fprintln(os.Stderr, "starting")
+	server := mcp.NewServer(&mcp.Implementation{Name: "logging", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "log", Description: "sends a log message to the client"}, Log)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Input struct {
+	To   int     `json:"to" jsonschema:"the number to count to"`
+	Wait float64 `json:"wait,omitempty" jsonschema:"seconds to wait after counting before answering"`
+}
+
+type Output struct {
+	Counted int `json:"counted" jsonschema:"the number counted to"`
+}
+
+func Count(ctx context.Context, req *mcp.CallToolRequest, input Input) (
+	*mcp.CallToolResult,
+	Output,
+	error,
+) {
+	token := req.Params.GetProgressToken()
+	for i := 1; i <= input.To; i++ {
+		if token == nil {
+			continue
+		}
+		err := req.Session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{
+			ProgressToken: token,
+			Progress:      float64(i),
+			Total:         float64(input.To),
+			Message:       fmt.Sprintf("counted %d", i),
+		})
+		if err != nil {
+			return nil, Output{}, err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, Output{}, ctx.Err()
+	case <-time.After(time.Duration(input.Wait * float64(time.Second))):
+	}
+	return nil, Output{Counted: input.To}, nil
+}
+
+func main() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "progress", Version: "v1.0.0"}, nil)
+	mcp.AddTool(server, &mcp.Tool{Name: "count", Description: "counts to a number, reporting progress along the way"}, Count)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
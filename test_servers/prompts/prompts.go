@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func GreetPrompt(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	name := req.Params.Arguments["name"]
+	return &mcp.GetPromptResult{
+		Description: "Greets " + name,
+		Messages: []*mcp.PromptMessage{
+			{Role: "user", Content: &mcp.TextContent{Text: "Please greet " + name + "."}},
+			{Role: "assistant", Content: &mcp.TextContent{Text: "Salutations, " + name + "."}},
+			{Role: "user", Content: &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: "file:///motto.txt", MIMEType: "text/plain", Text: "Salutations are in order."}}},
+		},
+	}, nil
+}
+
+func main() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "prompts", Version: "v1.0.0"}, nil)
+	server.AddPrompt(&mcp.Prompt{
+		Name:        "greet",
+		Description: "greets someone",
+		Arguments:   []*mcp.PromptArgument{{Name: "name", Description: "the name of the person to greet", Required: true}},
+	}, GreetPrompt)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func ReadMotto(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{URI: req.Params.URI, MIMEType: "text/plain", Text: "Salutations are in order."}},
+	}, nil
+}
+
+func ReadGreeting(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	name := strings.TrimPrefix(req.Params.URI, "greeting://")
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{URI: req.Params.URI, MIMEType: "text/plain", Text: "Salutations, " + name + "."}},
+	}, nil
+}
+
+func main() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "resources", Version: "v1.0.0"}, nil)
+	server.AddResource(&mcp.Resource{Name: "motto", URI: "file:///motto.txt", MIMEType: "text/plain", Description: "the motto of the greeter"}, ReadMotto)
+	server.AddResourceTemplate(&mcp.ResourceTemplate{Name: "greeting", URITemplate: "greeting://{name}", MIMEType: "text/plain", Description: "a greeting for someone"}, ReadGreeting)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
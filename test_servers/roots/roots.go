@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type Input struct{}
+
+type Output struct {
+	Roots   []string `json:"roots" jsonschema:"the URIs of the roots announced by the client"`
+	Changes int64    `json:"changes" jsonschema:"the number of times the client said its roots changed"`
+}
+
+var changes atomic.Int64
+
+func ListRoots(ctx context.Context, req *mcp.CallToolRequest, input Input) (
+	*mcp.CallToolResult,
+	Output,
+	error,
+) {
+	res, err := req.Session.ListRoots(ctx, nil)
+	if err != nil {
+		return nil, Output{}, err
+	}
+	roots := []string{}
+	for _, root := range res.Roots {
+		roots = append(roots, root.URI)
+	}
+	return nil, Output{Roots: roots, Changes: changes.Load()}, nil
+}
+
+func main() {
+	server := mcp.NewServer(&mcp.Implementation{Name: "roots", Version: "v1.0.0"}, &mcp.ServerOptions{
+		RootsListChangedHandler: func(ctx context.Context, req *mcp.RootsListChangedRequest) {
+			changes.Add(1)
+		},
+	})
+	mcp.AddTool(server, &mcp.Tool{Name: "listroots", Description: "lists the roots announced by the client"}, ListRoots)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}
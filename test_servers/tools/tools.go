@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type LearnInput struct {
+	Name string `json:"name" jsonschema:"the name of the tool to learn"`
+}
+
+type Output struct {
+	Said string `json:"said" jsonschema:"what the tool said"`
+}
+
+var server = mcp.NewServer(&mcp.Implementation{Name: "tools", Version: "v1.0.0"}, nil)
+
+// Adds a tool to the server, which notifies its clients that its tools changed.
+func Learn(ctx context.Context, req *mcp.CallToolRequest, input LearnInput) (
+	*mcp.CallToolResult,
+	Output,
+	error,
+) {
+	name := input.Name
+	mcp.AddTool(server, &mcp.Tool{Name: name, Description: "a learned tool"}, func(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, Output, error) {
+		return nil, Output{Said: "I am " + name}, nil
+	})
+	return nil, Output{Said: "learned " + name}, nil
+}
+
+func main() {
+	mcp.AddTool(server, &mcp.Tool{Name: "learn", Description: "adds a tool with the given name"}, Learn)
+	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
+		log.Fatal(err)
+	}
+}